@@ -0,0 +1,253 @@
+package chess
+
+import (
+	"math/bits"
+	"sync"
+)
+
+// Bitboard is a set of squares, one bit per square, with bit 0 for a1, bit 7 for h1 and bit 63 for h8
+type Bitboard uint64
+
+func squareIndex(square *Square) int {
+	return int(square.Rank-1)*8 + int(square.File-1)
+}
+
+func squareFromIndex(idx int) *Square {
+	return &Square{uint8(idx/8) + 1, uint8(idx%8) + 1}
+}
+
+func SquareBitboard(square *Square) Bitboard {
+	return Bitboard(1) << squareIndex(square)
+}
+
+func (bitboard Bitboard) Has(square *Square) bool {
+	return bitboard&SquareBitboard(square) != 0
+}
+
+func (bitboard Bitboard) Count() int {
+	return bits.OnesCount64(uint64(bitboard))
+}
+
+// Squares lists the squares of the set from a1 to h8, rank by rank
+func (bitboard Bitboard) Squares() []*Square {
+	squares := make([]*Square, 0, bitboard.Count())
+	for remaining := bitboard; remaining != 0; remaining &= remaining - 1 {
+		squares = append(squares, squareFromIndex(remaining.lowestIndex()))
+	}
+	return squares
+}
+
+func (bitboard Bitboard) lowestIndex() int {
+	return bits.TrailingZeros64(uint64(bitboard))
+}
+
+func (bitboard Bitboard) highestIndex() int {
+	return 63 - bits.LeadingZeros64(uint64(bitboard))
+}
+
+// direction is a step on the board in ranks and files. The first four are straight and the last four diagonal.
+type direction int
+
+const (
+	dirNorth direction = iota
+	dirSouth
+	dirEast
+	dirWest
+	dirNorthEast
+	dirNorthWest
+	dirSouthEast
+	dirSouthWest
+)
+
+var directionSteps = [8][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}, {1, 1}, {1, -1}, {-1, 1}, {-1, -1}}
+
+// isIncreasing reports whether the direction walks towards higher square indexes, so the nearest square of a ray
+// is its lowest bit
+func (dir direction) isIncreasing() bool {
+	return dir == dirNorth || dir == dirEast || dir == dirNorthEast || dir == dirNorthWest
+}
+
+var (
+	straightDirections = []direction{dirNorth, dirSouth, dirEast, dirWest}
+	diagonalDirections = []direction{dirNorthEast, dirNorthWest, dirSouthEast, dirSouthWest}
+)
+
+// attackTables holds the precomputed attacks of every piece from every square. The sliding piece attacks are
+// looked up with magic multiplication of the blocking pieces, see magicEntry.
+type attackTables struct {
+	knight [64]Bitboard
+	king   [64]Bitboard
+	// pawn is indexed by 0 for white and 1 for black
+	pawn   [2][64]Bitboard
+	rays   [8][64]Bitboard
+	rook   [64]magicEntry
+	bishop [64]magicEntry
+}
+
+var tables *attackTables
+var tablesOnce sync.Once
+
+// getAttackTables builds the tables on first use, which takes some tens of milliseconds for finding the magics
+func getAttackTables() *attackTables {
+	tablesOnce.Do(func() {
+		tables = newAttackTables()
+	})
+	return tables
+}
+
+func newAttackTables() *attackTables {
+	newTables := &attackTables{}
+	for idx := 0; idx < 64; idx++ {
+		rank, file := idx/8, idx%8
+		for _, step := range [][2]int{{2, 1}, {2, -1}, {1, 2}, {1, -2}, {-1, 2}, {-1, -2}, {-2, 1}, {-2, -1}} {
+			newTables.knight[idx] |= stepBitboard(rank, file, step[0], step[1])
+		}
+		for _, step := range directionSteps {
+			newTables.king[idx] |= stepBitboard(rank, file, step[0], step[1])
+		}
+		newTables.pawn[0][idx] = stepBitboard(rank, file, 1, -1) | stepBitboard(rank, file, 1, 1)
+		newTables.pawn[1][idx] = stepBitboard(rank, file, -1, -1) | stepBitboard(rank, file, -1, 1)
+		for dir := range directionSteps {
+			newTables.rays[dir][idx] = slidingAttacks(idx, 0, []direction{direction(dir)})
+		}
+	}
+	for idx := 0; idx < 64; idx++ {
+		rng := xorshiftRand(magicSeeds[idx/8])
+		newTables.rook[idx] = findMagic(idx, straightDirections, &rng)
+		rng = xorshiftRand(magicSeeds[idx/8])
+		newTables.bishop[idx] = findMagic(idx, diagonalDirections, &rng)
+	}
+	return newTables
+}
+
+func stepBitboard(rank int, file int, rankStep int, fileStep int) Bitboard {
+	rank, file = rank+rankStep, file+fileStep
+	if rank < 0 || rank > 7 || file < 0 || file > 7 {
+		return 0
+	}
+	return Bitboard(1) << (rank*8 + file)
+}
+
+// slidingAttacks walks the rays from the square, stopping on the first occupied square of each ray
+func slidingAttacks(idx int, occupancy Bitboard, dirs []direction) Bitboard {
+	var attacks Bitboard
+	for _, dir := range dirs {
+		step := directionSteps[dir]
+		rank, file := idx/8, idx%8
+		for {
+			squareBitboard := stepBitboard(rank, file, step[0], step[1])
+			if squareBitboard == 0 {
+				break
+			}
+			rank, file = rank+step[0], file+step[1]
+			attacks |= squareBitboard
+			if occupancy&squareBitboard != 0 {
+				break
+			}
+		}
+	}
+	return attacks
+}
+
+func (tables *attackTables) rookAttacks(idx int, occupancy Bitboard) Bitboard {
+	return tables.rook[idx].attacks[tables.rook[idx].index(occupancy)]
+}
+
+func (tables *attackTables) bishopAttacks(idx int, occupancy Bitboard) Bitboard {
+	return tables.bishop[idx].attacks[tables.bishop[idx].index(occupancy)]
+}
+
+// nearestOnRay returns the index of the first occupied square from idx in the direction, or -1
+func (tables *attackTables) nearestOnRay(idx int, dir direction, occupancy Bitboard) int {
+	blockers := tables.rays[dir][idx] & occupancy
+	if blockers == 0 {
+		return -1
+	}
+	if dir.isIncreasing() {
+		return blockers.lowestIndex()
+	}
+	return blockers.highestIndex()
+}
+
+// magicEntry maps the pieces that can block a sliding piece on a square to its attacks. The relevant blockers are
+// multiplied by the magic number, which gathers them into the top bits of the product for use as a table index.
+type magicEntry struct {
+	mask    Bitboard
+	magic   uint64
+	shift   uint
+	attacks []Bitboard
+}
+
+func (entry *magicEntry) index(occupancy Bitboard) uint64 {
+	return (uint64(occupancy&entry.mask) * entry.magic) >> entry.shift
+}
+
+// xorshiftRand is a xorshift generator for the magics and Zobrist keys, seeded the same way every run so they come
+// out the same
+type xorshiftRand uint64
+
+// magicSeeds are seeds per rank that lead xorshiftRand to magics within a few tries, taken from Stockfish
+var magicSeeds = [8]uint64{728, 10316, 55013, 32803, 12281, 15100, 16645, 255}
+
+func (rng *xorshiftRand) next() uint64 {
+	*rng ^= *rng >> 12
+	*rng ^= *rng << 25
+	*rng ^= *rng >> 27
+	return uint64(*rng) * 2685821657736338717
+}
+
+// findMagic tries sparse random numbers until one maps every blocker set of the square to a table slot without
+// colliding with a blocker set that has different attacks
+func findMagic(idx int, dirs []direction, rng *xorshiftRand) magicEntry {
+	// the last square of each ray is attacked whether or not it is occupied, so it doesn't count as a blocker
+	var edges Bitboard
+	rank, file := idx/8, idx%8
+	if rank != 0 {
+		edges |= 0xff
+	}
+	if rank != 7 {
+		edges |= 0xff << 56
+	}
+	if file != 0 {
+		edges |= 0x0101010101010101
+	}
+	if file != 7 {
+		edges |= 0x8080808080808080
+	}
+	mask := slidingAttacks(idx, 0, dirs) &^ edges
+	bitCount := mask.Count()
+
+	occupancies := make([]Bitboard, 0, 1<<bitCount)
+	references := make([]Bitboard, 0, 1<<bitCount)
+	for occupancy := Bitboard(0); ; {
+		occupancies = append(occupancies, occupancy)
+		references = append(references, slidingAttacks(idx, occupancy, dirs))
+		occupancy = (occupancy - mask) & mask
+		if occupancy == 0 {
+			break
+		}
+	}
+
+	entry := magicEntry{mask: mask, shift: uint(64 - bitCount), attacks: make([]Bitboard, 1<<bitCount)}
+	usedBy := make([]int, 1<<bitCount)
+	for attempt := 1; ; attempt++ {
+		entry.magic = rng.next() & rng.next() & rng.next()
+		if bits.OnesCount64((uint64(mask)*entry.magic)>>56) < 6 {
+			continue
+		}
+		isCollision := false
+		for occIdx, occupancy := range occupancies {
+			tableIdx := entry.index(occupancy)
+			if usedBy[tableIdx] != attempt {
+				usedBy[tableIdx] = attempt
+				entry.attacks[tableIdx] = references[occIdx]
+			} else if entry.attacks[tableIdx] != references[occIdx] {
+				isCollision = true
+				break
+			}
+		}
+		if !isCollision {
+			return entry
+		}
+	}
+}
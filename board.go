@@ -1,6 +1,7 @@
 package chess
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -8,23 +9,45 @@ import (
 )
 
 type Board struct {
-	Pieces                  [8][8]Piece      `json:"pieces"`
-	OptEnPassantSquare      *Square          `json:"enPassantSquare"`
-	IsWhiteTurn             bool             `json:"isWhiteTurn"`
-	CanWhiteCastleQueenside bool             `json:"canWhiteCastleQueenside"`
-	CanWhiteCastleKingside  bool             `json:"canWhiteCastleKingside"`
-	CanBlackCastleQueenside bool             `json:"canBlackCastleQueenside"`
-	CanBlackCastleKingside  bool             `json:"canBlackCastleKingside"`
-	HalfMoveClockCount      uint8            `json:"halfMoveClockCount"`
-	FullMoveCount           uint16           `json:"fullMoveCount"`
-	RepetitionsByMiniFEN    map[string]uint8 `json:"repetitionsByMiniFEN"`
-	Result                  BoardResult      `json:"result"`
+	Pieces                  [8][8]Piece `json:"pieces"`
+	OptEnPassantSquare      *Square     `json:"enPassantSquare"`
+	IsWhiteTurn             bool        `json:"isWhiteTurn"`
+	CanWhiteCastleQueenside bool        `json:"canWhiteCastleQueenside"`
+	CanWhiteCastleKingside  bool        `json:"canWhiteCastleKingside"`
+	CanBlackCastleQueenside bool        `json:"canBlackCastleQueenside"`
+	CanBlackCastleKingside  bool        `json:"canBlackCastleKingside"`
+	// HalfMoveClockCount stops counting at 255, so it only stays exact when a half move rule is in play
+	HalfMoveClockCount uint8            `json:"halfMoveClockCount"`
+	FullMoveCount      uint16           `json:"fullMoveCount"`
+	RepetitionsByKey   map[uint64]uint8 `json:"repetitionsByKey"`
+	// Deprecated: repetitions are counted in RepetitionsByKey, and this is no longer filled in. Counts decoded
+	// from JSON written by older versions are moved over to RepetitionsByKey.
+	RepetitionsByMiniFEN map[string]uint8 `json:"repetitionsByMiniFEN,omitempty"`
+	Result               BoardResult      `json:"result"`
+	// IsChess960 switches castling to the Chess960 rules, where castling moves are written as the king moving
+	// onto the castling rook
+	IsChess960 bool `json:"isChess960,omitempty"`
+	// the castle rook files are the files the castling rooks start on, where 0 means the corner
+	WhiteKingsideRookFile  uint8 `json:"whiteKingsideRookFile,omitempty"`
+	WhiteQueensideRookFile uint8 `json:"whiteQueensideRookFile,omitempty"`
+	BlackKingsideRookFile  uint8 `json:"blackKingsideRookFile,omitempty"`
+	BlackQueensideRookFile uint8 `json:"blackQueensideRookFile,omitempty"`
+	// rules is nil for the FIDE rules. They are carried through JSON but not through FEN.
+	rules *Rules
+	// zobristKey is the Zobrist key of the position without the en passant file, which the BoardBuilder keeps up
+	// to date as it changes the board. It is only set when hasZobristKey is, boards written as struct literals
+	// work their key out on request.
+	zobristKey    uint64
+	hasZobristKey bool
 	// memoizers
 	optMaterialCount   *MaterialCount
 	optWhiteKingSquare *Square
 	optBlackKingSquare *Square
 }
 
+// NewBoard makes a board counting repetitions by mini FEN, as boards did before they counted them by Zobrist key.
+//
+// Deprecated: use NewBoardWithKeys. The mini FENs are converted to Zobrist keys, dropping any that can't be read.
 func NewBoard(pieces *[8][8]Piece,
 	enPassantSquare *Square,
 	isWhiteTurn bool,
@@ -37,16 +60,44 @@ func NewBoard(pieces *[8][8]Piece,
 	repetitionsByMiniFEN map[string]uint8,
 	result BoardResult,
 ) *Board {
-	return &Board{
+	return NewBoardWithKeys(pieces, enPassantSquare, isWhiteTurn,
+		canWhiteCastleKingside, canWhiteCastleQueenside,
+		canBlackCastleKingside, canBlackCastleQueenside,
+		halfMoveClockCount, fullMoveCount, repetitionsByKeyFromMiniFENs(repetitionsByMiniFEN), result)
+}
+
+// NewBoardWithKeys makes a board counting repetitions by Zobrist key, see Board.ZobristKey
+func NewBoardWithKeys(pieces *[8][8]Piece,
+	enPassantSquare *Square,
+	isWhiteTurn bool,
+	canWhiteCastleKingside bool,
+	canWhiteCastleQueenside bool,
+	canBlackCastleKingside bool,
+	canBlackCastleQueenside bool,
+	halfMoveClockCount uint8,
+	fullMoveCount uint16,
+	repetitionsByKey map[uint64]uint8,
+	result BoardResult,
+) *Board {
+	board := &Board{
 		*pieces, enPassantSquare, isWhiteTurn,
 		canWhiteCastleQueenside, canWhiteCastleKingside,
 		canBlackCastleQueenside, canBlackCastleKingside,
-		halfMoveClockCount, fullMoveCount, repetitionsByMiniFEN,
-		result, nil, nil, nil,
+		halfMoveClockCount, fullMoveCount, repetitionsByKey, nil,
+		result, false, 0, 0, 0, 0, nil, 0, false, nil, nil, nil,
 	}
+	board.zobristKey, board.hasZobristKey = board.computeZobristKey(), true
+	return board
 }
 
+// BoardFromFEN reads a FEN without validating the position, see BoardFromFENWithValidation
 func BoardFromFEN(fen string) (*Board, error) {
+	return BoardFromFENWithValidation(fen, FEN_VALIDATION_NONE)
+}
+
+// BoardFromFENWithValidation reads a FEN, validating the position as given. Errors for impossible positions wrap
+// the position errors, e.g. ErrNoKing.
+func BoardFromFENWithValidation(fen string, validation FENValidation) (*Board, error) {
 	fen = strings.TrimSpace(fen)
 	pieceByFENrune := map[rune]Piece{
 		'p': BLACK_PAWN,
@@ -77,8 +128,6 @@ func BoardFromFEN(fen string) (*Board, error) {
 	if len(fenSegs) != 6 {
 		return nil, fmt.Errorf("invalid FEN: wrong number of FEN segments. Expected 6 vs. actual %d", len(fenSegs))
 	}
-	miniFEN := strings.Join(fenSegs[:4], " ")
-	boardBuilder.WithRepetitionsByMiniFEN(map[string]uint8{miniFEN: uint8(1)})
 	for fenSegIdx, fenSeg := range fenSegs {
 		if fenSegIdx == 0 {
 			materialCountBuilder := NewMaterialCountBuilder()
@@ -129,18 +178,8 @@ func BoardFromFEN(fen string) (*Board, error) {
 			if fenSeg == "-" || fenSeg == "_" {
 				continue
 			}
-			for _, castleRightsRune := range []rune(fenSeg) {
-				if castleRightsRune == 'K' {
-					boardBuilder.WithCanWhiteCastleKingside(true)
-				} else if castleRightsRune == 'Q' {
-					boardBuilder.WithCanWhiteCastleQueenside(true)
-				} else if castleRightsRune == 'k' {
-					boardBuilder.WithCanBlackCastleKingside(true)
-				} else if castleRightsRune == 'q' {
-					boardBuilder.WithCanBlackCastleQueenside(true)
-				} else {
-					return nil, fmt.Errorf("invalid FEN: unknown castle rights specifier, got %c", castleRightsRune)
-				}
+			if err := readFENCastleRights(boardBuilder, fenSeg); err != nil {
+				return nil, err
 			}
 		} else if fenSegIdx == 3 {
 			if fenSeg == "-" || fenSeg == "_" {
@@ -176,18 +215,25 @@ func BoardFromFEN(fen string) (*Board, error) {
 		}
 	}
 
+	if validation != FEN_VALIDATION_NONE {
+		if err := validateBoard(boardBuilder, validation == FEN_VALIDATION_LENIENT); err != nil {
+			return nil, fmt.Errorf("invalid FEN: %w", err)
+		}
+	}
+	return finishBoard(boardBuilder), nil
+}
+
+// finishBoard starts the repetition history of a board set up from scratch and sets the result its position has
+func finishBoard(boardBuilder *BoardBuilder) *Board {
+	boardBuilder.WithRepetitionsByKey(map[uint64]uint8{boardBuilder.board.ZobristKey(): uint8(1)})
 	boardBuilder.WithResult(BOARD_RESULT_IN_PROGRESS)
 	prevBoard := NewBoardBuilder().FromBoard(boardBuilder.Build()).WithIsWhiteTurn(!boardBuilder.board.IsWhiteTurn).Build()
 	UpdateBoardResult(prevBoard, boardBuilder, 0)
-	return boardBuilder.Build(), nil
+	return boardBuilder.Build()
 }
 
 func GetInitBoard() *Board {
-	miniFEN := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -"
-	repetitionsByMiniFEN := map[string]uint8{
-		miniFEN: uint8(1),
-	}
-	return NewBoard(&[8][8]Piece{
+	board := NewBoardWithKeys(&[8][8]Piece{
 		{WHITE_ROOK, WHITE_KNIGHT, WHITE_BISHOP, WHITE_QUEEN, WHITE_KING, WHITE_BISHOP, WHITE_KNIGHT, WHITE_ROOK},
 		{WHITE_PAWN, WHITE_PAWN, WHITE_PAWN, WHITE_PAWN, WHITE_PAWN, WHITE_PAWN, WHITE_PAWN, WHITE_PAWN},
 		{EMPTY, EMPTY, EMPTY, EMPTY, EMPTY, EMPTY, EMPTY, EMPTY},
@@ -197,7 +243,9 @@ func GetInitBoard() *Board {
 		{BLACK_PAWN, BLACK_PAWN, BLACK_PAWN, BLACK_PAWN, BLACK_PAWN, BLACK_PAWN, BLACK_PAWN, BLACK_PAWN},
 		{BLACK_ROOK, BLACK_KNIGHT, BLACK_BISHOP, BLACK_QUEEN, BLACK_KING, BLACK_BISHOP, BLACK_KNIGHT, BLACK_ROOK},
 	}, nil, true, true, true, true,
-		true, 0, 1, repetitionsByMiniFEN, BOARD_RESULT_IN_PROGRESS)
+		true, 0, 1, make(map[uint64]uint8), BOARD_RESULT_IN_PROGRESS)
+	board.RepetitionsByKey[board.ZobristKey()] = 1
+	return board
 }
 
 func (board *Board) GetPieceOnSquare(square *Square) Piece {
@@ -237,6 +285,101 @@ func (board *Board) IsForcedDrawByMaterial() bool {
 	return true
 }
 
+// HasMatingMaterial reports whether the given side could still checkmate, see MaterialCount.HasMatingMaterial
+func (board *Board) HasMatingMaterial(isWhite bool) bool {
+	return board.ComputeMaterialCount().HasMatingMaterial(isWhite)
+}
+
+// CastleRookSquare returns the square the castling rook of the given side starts on, which is a corner square
+// unless the board is set up for Chess960
+func (board *Board) CastleRookSquare(isWhite bool, isKingside bool) *Square {
+	var file uint8
+	if isWhite && isKingside {
+		file = board.WhiteKingsideRookFile
+	} else if isWhite {
+		file = board.WhiteQueensideRookFile
+	} else if isKingside {
+		file = board.BlackKingsideRookFile
+	} else {
+		file = board.BlackQueensideRookFile
+	}
+	if file == 0 {
+		if isKingside {
+			file = 8
+		} else {
+			file = 1
+		}
+	}
+	return &Square{backRank(isWhite), file}
+}
+
+// boardFields is a Board without its methods, for encoding the exported fields with encoding/json
+type boardFields Board
+
+// boardJSON adds the rules to the exported fields, since they are only set through the BoardBuilder
+type boardJSON struct {
+	*boardFields
+	Rules *Rules `json:"rules,omitempty"`
+}
+
+func (board Board) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&boardJSON{(*boardFields)(&board), board.rules})
+}
+
+func (board *Board) UnmarshalJSON(data []byte) error {
+	decoded := boardJSON{boardFields: (*boardFields)(board)}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	board.rules = decoded.Rules
+	board.optMaterialCount, board.optWhiteKingSquare, board.optBlackKingSquare = nil, nil, nil
+	board.zobristKey, board.hasZobristKey = board.computeZobristKey(), true
+	if len(board.RepetitionsByMiniFEN) > 0 {
+		if board.RepetitionsByKey == nil {
+			board.RepetitionsByKey = make(map[uint64]uint8, len(board.RepetitionsByMiniFEN))
+		}
+		for key, count := range repetitionsByKeyFromMiniFENs(board.RepetitionsByMiniFEN) {
+			board.RepetitionsByKey[key] = count
+		}
+		board.RepetitionsByMiniFEN = nil
+	}
+	return nil
+}
+
+// Rules returns the draw rules the board plays by
+func (board *Board) Rules() *Rules {
+	if board.rules == nil {
+		return FIDERules()
+	}
+	return board.rules
+}
+
+// CanClaimDraw reports whether the player to move may claim a draw in the current position, and by which rule.
+// Claims are only possible while the game is in progress.
+func (board *Board) CanClaimDraw() (bool, BoardResult) {
+	if board.Result != BOARD_RESULT_IN_PROGRESS {
+		return false, board.Result
+	}
+	rules := board.Rules()
+	repetitions := board.RepetitionsByKey[board.ZobristKey()]
+	if rules.IsClaimableDrawByRepetition(repetitions) {
+		return true, repetitionDrawResult(rules.ClaimRepetitions)
+	}
+	if rules.IsClaimableDrawByHalfMoves(board.HalfMoveClockCount) {
+		return true, halfMoveDrawResult(rules.ClaimHalfMoves)
+	}
+	return false, BOARD_RESULT_IN_PROGRESS
+}
+
+// CanClaimDrawWithMove reports whether the player to move may claim a draw by announcing a move that creates
+// the repetition or completes the move count. The move is expected to be legal.
+func (board *Board) CanClaimDrawWithMove(move *Move) (bool, BoardResult) {
+	if board.Result != BOARD_RESULT_IN_PROGRESS {
+		return false, board.Result
+	}
+	return GetBoardFromMove(board, move).CanClaimDraw()
+}
+
 func (board *Board) HasLegalNextMove() bool {
 	moves := GetLegalMovesForKing(board)
 	if len(moves) == 0 {
@@ -301,7 +444,13 @@ func (board *Board) GetKingSquare(isWhiteKing bool) *Square {
 	}
 }
 
+// ToFEN writes the board as a FEN, using X-FEN for the castle rights of Chess960 boards
 func (board *Board) ToFEN() string {
+	return board.ToFENWithDialect(FEN_DIALECT_X_FEN)
+}
+
+// ToFENWithDialect writes the board as a FEN with the castle rights in the given dialect
+func (board *Board) ToFENWithDialect(dialect FENDialect) string {
 	var fenSegsBuilder strings.Builder
 
 	pieceRuneByPiece := []rune{'x', 'P', 'N', 'B', 'R', 'Q', 'K', 'p', 'n', 'b', 'r', 'q', 'k'}
@@ -340,23 +489,7 @@ func (board *Board) ToFEN() string {
 	fenSegsBuilder.WriteRune(turnRune)
 	fenSegsBuilder.WriteRune(' ')
 
-	fenCastleSeg := make([]rune, 0, 4)
-	if board.CanWhiteCastleKingside {
-		fenCastleSeg = append(fenCastleSeg, 'K')
-	}
-	if board.CanWhiteCastleQueenside {
-		fenCastleSeg = append(fenCastleSeg, 'Q')
-	}
-	if board.CanBlackCastleKingside {
-		fenCastleSeg = append(fenCastleSeg, 'k')
-	}
-	if board.CanBlackCastleQueenside {
-		fenCastleSeg = append(fenCastleSeg, 'q')
-	}
-	if len(fenCastleSeg) == 0 {
-		fenCastleSeg = append(fenCastleSeg, '-')
-	}
-	fenSegsBuilder.WriteString(string(fenCastleSeg))
+	fenSegsBuilder.WriteString(board.fenCastleRights(dialect))
 	fenSegsBuilder.WriteRune(' ')
 
 	if board.OptEnPassantSquare != nil {
@@ -392,30 +525,32 @@ func (board *Board) String() string {
 	return fmt.Sprintf("Board<%s>", board.ToFEN())
 }
 
-// pieceSquareByFile strictly used for finding origin square of a move expressed in algebraic notation
+// pieceSquaresByRank strictly used for finding origin squares of a move expressed in algebraic notation
 // where the origin square is not explicitly given.
-func (board *Board) pieceSquareByRank(piece Piece, rank uint8) (*Square, error) {
+func (board *Board) pieceSquaresByRank(piece Piece, rank uint8) []*Square {
+	var out = make([]*Square, 0)
 	for file := uint8(1); file < 9; file++ {
 		sqr := &Square{rank, file}
 		_piece := board.GetPieceOnSquare(sqr)
 		if piece == _piece {
-			return sqr, nil
+			out = append(out, sqr)
 		}
 	}
-	return nil, fmt.Errorf("could not find peice %s on rank %s on %s", piece, string(rank), board.ToFEN())
+	return out
 }
 
-// pieceSquareByFile strictly used for finding origin square of a move expressed in algebraic notation
+// pieceSquaresByFile strictly used for finding origin squares of a move expressed in algebraic notation
 // where the origin square is not explicitly given.
-func (board *Board) pieceSquareByFile(piece Piece, file uint8) (*Square, error) {
+func (board *Board) pieceSquaresByFile(piece Piece, file uint8) []*Square {
+	var out = make([]*Square, 0)
 	for rank := uint8(1); rank < 9; rank++ {
 		sqr := &Square{rank, file}
 		_piece := board.GetPieceOnSquare(sqr)
 		if piece == _piece {
-			return sqr, nil
+			out = append(out, sqr)
 		}
 	}
-	return nil, fmt.Errorf("could not find piece %s on file %s of %s", piece, string(file), board.ToFEN())
+	return out
 }
 
 func (board *Board) pieceSquaresOnBoard(piece Piece) []*Square {
@@ -6,13 +6,15 @@ type BoardBuilder struct {
 
 func NewBoardBuilder() *BoardBuilder {
 	board := Board{}
-	board.RepetitionsByMiniFEN = make(map[string]uint8)
+	board.RepetitionsByKey = make(map[uint64]uint8)
+	board.zobristKey, board.hasZobristKey = board.computeZobristKey(), true
 	return &BoardBuilder{
 		board: &board,
 	}
 }
 
 func (bb *BoardBuilder) WithPieces(pieces [8][8]Piece) *BoardBuilder {
+	bb.board.zobristKey ^= piecesZobristKey(&bb.board.Pieces) ^ piecesZobristKey(&pieces)
 	bb.board.Pieces = pieces
 	bb.board.optMaterialCount = nil
 	return bb
@@ -21,6 +23,13 @@ func (bb *BoardBuilder) WithPieces(pieces [8][8]Piece) *BoardBuilder {
 func (bb *BoardBuilder) WithPiece(piece Piece, square *Square) *BoardBuilder {
 	prevPiece := bb.board.Pieces[square.Rank-1][square.File-1]
 	bb.board.Pieces[square.Rank-1][square.File-1] = piece
+	idx := squareIndex(square)
+	if prevPiece != EMPTY {
+		bb.board.zobristKey ^= zobrist.pieces[prevPiece][idx]
+	}
+	if piece != EMPTY {
+		bb.board.zobristKey ^= zobrist.pieces[piece][idx]
+	}
 	if bb.board.optMaterialCount != nil {
 		materialCountBuilder := NewMaterialCountBuilder().WithMaterialCount(bb.board.optMaterialCount)
 		if prevPiece != EMPTY {
@@ -50,29 +59,42 @@ func (bb *BoardBuilder) WithEnPassantSquare(enPassantSquare *Square) *BoardBuild
 }
 
 func (bb *BoardBuilder) WithIsWhiteTurn(isWhiteTurn bool) *BoardBuilder {
+	if bb.board.IsWhiteTurn != isWhiteTurn {
+		bb.board.zobristKey ^= zobrist.isBlackTurn
+	}
 	bb.board.IsWhiteTurn = isWhiteTurn
 	return bb
 }
 
 func (bb *BoardBuilder) WithCanWhiteCastleQueenside(canWhiteCastleQueenside bool) *BoardBuilder {
+	bb.toggleCastleRightKey(bb.board.CanWhiteCastleQueenside != canWhiteCastleQueenside, true, false)
 	bb.board.CanWhiteCastleQueenside = canWhiteCastleQueenside
 	return bb
 }
 
 func (bb *BoardBuilder) WithCanWhiteCastleKingside(canWhiteCastleKingside bool) *BoardBuilder {
+	bb.toggleCastleRightKey(bb.board.CanWhiteCastleKingside != canWhiteCastleKingside, true, true)
 	bb.board.CanWhiteCastleKingside = canWhiteCastleKingside
 	return bb
 }
 func (bb *BoardBuilder) WithCanBlackCastleQueenside(canBlackCastleQueenside bool) *BoardBuilder {
+	bb.toggleCastleRightKey(bb.board.CanBlackCastleQueenside != canBlackCastleQueenside, false, false)
 	bb.board.CanBlackCastleQueenside = canBlackCastleQueenside
 	return bb
 }
 
 func (bb *BoardBuilder) WithCanBlackCastleKingside(canBlackCastleKingside bool) *BoardBuilder {
+	bb.toggleCastleRightKey(bb.board.CanBlackCastleKingside != canBlackCastleKingside, false, true)
 	bb.board.CanBlackCastleKingside = canBlackCastleKingside
 	return bb
 }
 
+func (bb *BoardBuilder) toggleCastleRightKey(isChanged bool, isWhite bool, isKingside bool) {
+	if isChanged {
+		bb.board.zobristKey ^= zobrist.castleRights[castleIdx(isWhite, isKingside)]
+	}
+}
+
 func (bb *BoardBuilder) WithHalfMoveClockCount(halfMoveClockCount uint8) *BoardBuilder {
 	bb.board.HalfMoveClockCount = halfMoveClockCount
 	return bb
@@ -83,19 +105,34 @@ func (bb *BoardBuilder) WithFullMoveCount(fullMoveCount uint16) *BoardBuilder {
 	return bb
 }
 
-func (bb *BoardBuilder) WithRepetitionsByMiniFEN(repetitionsByMiniFEN map[string]uint8) *BoardBuilder {
-	bb.board.RepetitionsByMiniFEN = repetitionsByMiniFEN
+func (bb *BoardBuilder) WithRepetitionsByKey(repetitionsByKey map[uint64]uint8) *BoardBuilder {
+	bb.board.RepetitionsByKey = repetitionsByKey
 	return bb
 }
 
+// Deprecated: use WithRepetitionsByKey. The mini FENs are converted to Zobrist keys, dropping any that can't be
+// read.
+func (bb *BoardBuilder) WithRepetitionsByMiniFEN(repetitionsByMiniFEN map[string]uint8) *BoardBuilder {
+	return bb.WithRepetitionsByKey(repetitionsByKeyFromMiniFENs(repetitionsByMiniFEN))
+}
+
+// Deprecated: use WithKeyCount. The mini FEN is converted to its Zobrist key, and ignored if it can't be read.
 func (bb *BoardBuilder) WithMiniFENCount(miniFEN string, count uint8) *BoardBuilder {
-	repsByMiniFENCopy := make(map[string]uint8)
-	for key, value := range bb.board.RepetitionsByMiniFEN {
-		repsByMiniFENCopy[key] = value
+	if key, ok := miniFENZobristKey(miniFEN); ok {
+		bb.WithKeyCount(key, count)
 	}
+	return bb
+}
 
-	bb.board.RepetitionsByMiniFEN = repsByMiniFENCopy
-	bb.board.RepetitionsByMiniFEN[miniFEN] = count
+// WithKeyCount sets how often the position with the Zobrist key has occurred, see Board.ZobristKey
+func (bb *BoardBuilder) WithKeyCount(key uint64, count uint8) *BoardBuilder {
+	repsByKeyCopy := make(map[uint64]uint8, len(bb.board.RepetitionsByKey)+1)
+	for prevKey, value := range bb.board.RepetitionsByKey {
+		repsByKeyCopy[prevKey] = value
+	}
+
+	bb.board.RepetitionsByKey = repsByKeyCopy
+	bb.board.RepetitionsByKey[key] = count
 	return bb
 }
 
@@ -104,8 +141,36 @@ func (bb *BoardBuilder) WithResult(result BoardResult) *BoardBuilder {
 	return bb
 }
 
+func (bb *BoardBuilder) WithIsChess960(isChess960 bool) *BoardBuilder {
+	bb.board.IsChess960 = isChess960
+	return bb
+}
+
+// WithCastleRookFile sets the file the castling rook starts on, 0 meaning the corner
+func (bb *BoardBuilder) WithCastleRookFile(isWhite bool, isKingside bool, file uint8) *BoardBuilder {
+	if isWhite && isKingside {
+		bb.board.WhiteKingsideRookFile = file
+	} else if isWhite {
+		bb.board.WhiteQueensideRookFile = file
+	} else if isKingside {
+		bb.board.BlackKingsideRookFile = file
+	} else {
+		bb.board.BlackQueensideRookFile = file
+	}
+	return bb
+}
+
+// WithRules sets the draw rules, nil meaning the FIDE rules
+func (bb *BoardBuilder) WithRules(rules *Rules) *BoardBuilder {
+	bb.board.rules = rules
+	return bb
+}
+
 func (bb *BoardBuilder) FromBoard(board *Board) *BoardBuilder {
 	boardCopy := *board
+	if !boardCopy.hasZobristKey {
+		boardCopy.zobristKey, boardCopy.hasZobristKey = boardCopy.computeZobristKey(), true
+	}
 	bb.board = &boardCopy
 
 	return bb
@@ -3,11 +3,13 @@ package chess
 type BoardResult string
 
 const (
-	BOARD_RESULT_IN_PROGRESS                   BoardResult = "in_progress"
-	BOARD_RESULT_WHITE_WINS_BY_CHECKMATE       BoardResult = "white_wins_by_checkmate"
-	BOARD_RESULT_BLACK_WINS_BY_CHECKMATE       BoardResult = "black_wins_by_checkmate"
-	BOARD_RESULT_DRAW_BY_STALEMATE             BoardResult = "draw_by_stalemate"
-	BOARD_RESULT_DRAW_BY_INSUFFICIENT_MATERIAL BoardResult = "draw_by_insufficient_material"
-	BOARD_RESULT_DRAW_BY_THREEFOLD_REPETITION  BoardResult = "draw_by_threefold_repetition"
-	BOARD_RESULT_DRAW_BY_FIFTY_MOVE_RULE       BoardResult = "draw_by_fifty_move_rule"
+	BOARD_RESULT_IN_PROGRESS                    BoardResult = "in_progress"
+	BOARD_RESULT_WHITE_WINS_BY_CHECKMATE        BoardResult = "white_wins_by_checkmate"
+	BOARD_RESULT_BLACK_WINS_BY_CHECKMATE        BoardResult = "black_wins_by_checkmate"
+	BOARD_RESULT_DRAW_BY_STALEMATE              BoardResult = "draw_by_stalemate"
+	BOARD_RESULT_DRAW_BY_INSUFFICIENT_MATERIAL  BoardResult = "draw_by_insufficient_material"
+	BOARD_RESULT_DRAW_BY_THREEFOLD_REPETITION   BoardResult = "draw_by_threefold_repetition"
+	BOARD_RESULT_DRAW_BY_FIFTY_MOVE_RULE        BoardResult = "draw_by_fifty_move_rule"
+	BOARD_RESULT_DRAW_BY_FIVEFOLD_REPETITION    BoardResult = "draw_by_fivefold_repetition"
+	BOARD_RESULT_DRAW_BY_SEVENTY_FIVE_MOVE_RULE BoardResult = "draw_by_seventy_five_move_rule"
 )
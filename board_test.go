@@ -2,6 +2,8 @@ package chess_test
 
 import (
 	"encoding/json"
+	"errors"
+
 	chess "github.com/CameronHonis/chess"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -110,6 +112,163 @@ var _ = Describe("Board", func() {
 			})
 		})
 	})
+	DescribeTable("::HasMatingMaterial", func(fen string, isWhite bool, expHasMatingMaterial bool) {
+		board, _ := chess.BoardFromFEN(fen)
+		Expect(board.HasMatingMaterial(isWhite)).To(Equal(expHasMatingMaterial))
+	},
+		Entry("lone king", "8/8/8/8/7K/8/8/k6r w - - 0 1", true, false),
+		Entry("a pawn", "8/8/8/8/7K/8/P7/k7 w - - 0 1", true, true),
+		Entry("a rook", "8/8/8/8/7K/8/8/k6r w - - 0 1", false, true),
+		Entry("lone knight against a bare king", "8/8/8/8/4N2K/8/8/k7 w - - 0 1", true, false),
+		Entry("lone knight against a pawn", "8/8/8/8/4N2K/8/p7/k7 w - - 0 1", true, true),
+		Entry("two knights", "8/8/8/8/4NN1K/8/8/k7 w - - 0 1", true, true),
+		Entry("knight and bishop", "8/8/8/8/4NB1K/8/8/k7 w - - 0 1", true, true),
+		Entry("same colored bishops against a bare king", "8/4B3/3B4/8/7K/8/8/k7 w - - 0 1", true, false),
+		Entry("opposite colored bishops", "8/8/8/8/4BB1K/8/8/k7 w - - 0 1", true, true),
+		Entry("bishop against a same colored bishop", "8/4B3/8/8/7K/8/8/k1b5 w - - 0 1", true, false),
+		Entry("bishop against an opposite colored bishop", "8/4B3/8/8/7K/8/8/kb6 w - - 0 1", true, true),
+		Entry("bishop against a knight", "8/4B3/8/8/7K/8/8/kn6 w - - 0 1", true, true),
+	)
+
+	Describe("::ZobristKey", func() {
+		It("is kept up to date through moves", func() {
+			board := chess.GetInitBoard()
+			for _, san := range []string{"e4", "d5", "exd5", "Qxd5", "Nc3", "Qa5", "Nf3", "Nf6", "Bc4", "e5", "O-O", "e4", "d4", "exd3"} {
+				move, err := chess.MoveFromAlgebraic(san, board)
+				Expect(err).ToNot(HaveOccurred())
+				board = chess.GetBoardFromMove(board, move)
+				boardFromFEN, _ := chess.BoardFromFEN(board.ToFEN())
+				Expect(board.ZobristKey()).To(Equal(boardFromFEN.ZobristKey()), "after %s", san)
+				Expect(board.ZobristKey()).To(Equal(chess.NewPosition(board).Key()), "after %s", san)
+			}
+		})
+		It("counts a position as repeated regardless of an en passant square no pawn can use", func() {
+			board := chess.GetInitBoard()
+			for _, san := range []string{"e4", "Nf6", "Nf3", "Ng8", "Ng1"} {
+				move, err := chess.MoveFromAlgebraic(san, board)
+				Expect(err).ToNot(HaveOccurred())
+				board = chess.GetBoardFromMove(board, move)
+			}
+			Expect(board.RepetitionsByKey[board.ZobristKey()]).To(Equal(uint8(2)))
+		})
+		It("is right for boards decoded from JSON and for fields set through the builder", func() {
+			boardJson, err := json.Marshal(chess.GetInitBoard())
+			Expect(err).ToNot(HaveOccurred())
+			board := &chess.Board{}
+			Expect(json.Unmarshal(boardJson, board)).To(Succeed())
+			Expect(board.ZobristKey()).To(Equal(chess.GetInitBoard().ZobristKey()))
+			for _, san := range []string{"Nf3", "Nf6", "Ng1", "Ng8", "Nf3", "Nf6", "Ng1", "Ng8"} {
+				move, moveErr := chess.MoveFromAlgebraic(san, board)
+				Expect(moveErr).ToNot(HaveOccurred())
+				board = chess.GetBoardFromMove(board, move)
+			}
+			Expect(board.RepetitionsByKey[board.ZobristKey()]).To(Equal(uint8(3)))
+
+			board = chess.NewBoardBuilder().FromBoard(board).WithIsWhiteTurn(false).WithCanWhiteCastleKingside(false).Build()
+			boardFromFEN, _ := chess.BoardFromFEN(board.ToFEN())
+			Expect(board.ZobristKey()).To(Equal(boardFromFEN.ZobristKey()))
+		})
+		It("is right for boards written as struct literals", func() {
+			initBoard := chess.GetInitBoard()
+			board := &chess.Board{Pieces: initBoard.Pieces, IsWhiteTurn: true, CanWhiteCastleKingside: true,
+				CanWhiteCastleQueenside: true, CanBlackCastleKingside: true, CanBlackCastleQueenside: true}
+			Expect(board.ZobristKey()).To(Equal(initBoard.ZobristKey()))
+			Expect(chess.NewBoardBuilder().FromBoard(board).Build().ZobristKey()).To(Equal(initBoard.ZobristKey()))
+		})
+		It("counts repetitions given to NewBoard by mini FEN under their keys", func() {
+			initBoard := chess.GetInitBoard()
+			board := chess.NewBoard(&initBoard.Pieces, nil, true, true, true, true, true, 0, 1,
+				map[string]uint8{initBoard.ToMiniFEN(): 2}, chess.BOARD_RESULT_IN_PROGRESS)
+			Expect(board.RepetitionsByKey).To(Equal(map[uint64]uint8{initBoard.ZobristKey(): 2}))
+			boardWithKeys := chess.NewBoardWithKeys(&initBoard.Pieces, nil, true, true, true, true, true, 0, 1,
+				map[uint64]uint8{initBoard.ZobristKey(): 2}, chess.BOARD_RESULT_IN_PROGRESS)
+			Expect(boardWithKeys).To(Equal(board))
+		})
+		It("reads repetitions counted by mini FEN in older JSON", func() {
+			board := chess.GetInitBoard()
+			boardJson := []byte(`{"isWhiteTurn": true, "repetitionsByMiniFEN": {"` + board.ToMiniFEN() + `": 2}}`)
+			decodedBoard := &chess.Board{}
+			Expect(json.Unmarshal(boardJson, decodedBoard)).To(Succeed())
+			Expect(decodedBoard.RepetitionsByMiniFEN).To(BeNil())
+			Expect(decodedBoard.RepetitionsByKey).To(Equal(map[uint64]uint8{board.ZobristKey(): 2}))
+		})
+		It("tells apart positions where an en passant capture is possible", func() {
+			withEnPassant, _ := chess.BoardFromFEN("rnbqkbnr/ppp1pppp/8/8/3pP3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1")
+			withoutEnPassant, _ := chess.BoardFromFEN("rnbqkbnr/ppp1pppp/8/8/3pP3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1")
+			Expect(withEnPassant.ZobristKey()).ToNot(Equal(withoutEnPassant.ZobristKey()))
+		})
+	})
+
+	DescribeTable("::PolyglotKey", func(fen string, expKey uint64) {
+		board, err := chess.BoardFromFEN(fen)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(board.PolyglotKey()).To(Equal(expKey))
+	},
+		Entry("initial board", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", uint64(0x463b96181691fc9c)),
+		Entry("e4", "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1", uint64(0x823c9b50fd114196)),
+		Entry("e4 d5", "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2", uint64(0x0756b94461c50fb0)),
+		Entry("e4 d5 e5", "rnbqkbnr/ppp1pppp/8/3pP3/8/8/PPPP1PPP/RNBQKBNR b KQkq - 0 2", uint64(0x662fafb965db29d4)),
+		Entry("e4 d5 e5 f5, en passant possible", "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", uint64(0x22a48b5a8e47ff78)),
+		Entry("e4 d5 e5 f5 Ke2", "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPPKPPP/RNBQ1BNR b kq - 0 3", uint64(0x652a607ca3f242c1)),
+		Entry("e4 d5 e5 f5 Ke2 Kf7", "rnbq1bnr/ppp1pkpp/8/3pPp2/8/8/PPPPKPPP/RNBQ1BNR w - - 0 4", uint64(0x00fdd303c946bdd9)),
+		Entry("a4 b5 h4 b4 c4", "rnbqkbnr/p1pppppp/8/8/PpP4P/8/1P1PPPP1/RNBQKBNR b KQkq c3 0 3", uint64(0x3c8123ea7b067637)),
+		Entry("a4 b5 h4 b4 c4 bxc3 Ra3", "rnbqkbnr/p1pppppp/8/8/P6P/R1p5/1P1PPPP1/1NBQKBNR b Kkq - 0 4", uint64(0x5c3f9b829b279560)),
+	)
+
+	Describe("::CanClaimDraw", func() {
+		When("the position has occurred three times", func() {
+			It("returns a claimable threefold repetition", func() {
+				board := chess.GetInitBoard()
+				board.RepetitionsByKey[board.ZobristKey()] = 3
+				canClaim, result := board.CanClaimDraw()
+				Expect(canClaim).To(BeTrue())
+				Expect(result).To(Equal(chess.BOARD_RESULT_DRAW_BY_THREEFOLD_REPETITION))
+			})
+			It("counts repetitions set by mini FEN through the deprecated builder methods", func() {
+				board := chess.GetInitBoard()
+				board = chess.NewBoardBuilder().FromBoard(board).WithMiniFENCount(board.ToMiniFEN(), 3).Build()
+				canClaim, _ := board.CanClaimDraw()
+				Expect(canClaim).To(BeTrue())
+			})
+		})
+		When("fifty moves were played without a capture or pawn move", func() {
+			It("returns a claimable fifty move draw", func() {
+				board, _ := chess.BoardFromFEN("k7/r7/8/8/8/4KQ2/8/8 w - - 100 80")
+				canClaim, result := board.CanClaimDraw()
+				Expect(canClaim).To(BeTrue())
+				Expect(result).To(Equal(chess.BOARD_RESULT_DRAW_BY_FIFTY_MOVE_RULE))
+			})
+		})
+		When("the rules disable claims", func() {
+			It("returns false", func() {
+				fen := "k7/r7/8/8/8/4KQ2/8/8 w - - 100 80"
+				board, _ := chess.BoardFromFEN(fen)
+				board = chess.NewBoardBuilder().FromBoard(board).WithRules(&chess.Rules{AutoHalfMoves: 150}).Build()
+				canClaim, _ := board.CanClaimDraw()
+				Expect(canClaim).To(BeFalse())
+			})
+		})
+		When("no draw can be claimed", func() {
+			It("returns false", func() {
+				canClaim, result := chess.GetInitBoard().CanClaimDraw()
+				Expect(canClaim).To(BeFalse())
+				Expect(result).To(Equal(chess.BOARD_RESULT_IN_PROGRESS))
+			})
+		})
+	})
+
+	Describe("::CanClaimDrawWithMove", func() {
+		When("the move completes fifty moves without a capture or pawn move", func() {
+			It("returns a claimable fifty move draw", func() {
+				board, _ := chess.BoardFromFEN("k7/r7/8/8/8/4KQ2/8/8 w - - 99 80")
+				move := &chess.Move{chess.WHITE_KING, &chess.Square{3, 5}, &chess.Square{2, 5}, chess.EMPTY, []*chess.Square{}, chess.EMPTY}
+				canClaim, result := board.CanClaimDrawWithMove(move)
+				Expect(canClaim).To(BeTrue())
+				Expect(result).To(Equal(chess.BOARD_RESULT_DRAW_BY_FIFTY_MOVE_RULE))
+			})
+		})
+	})
+
 	Describe("::HasLegalNextMove", func() {
 		var board *chess.Board
 		When("the board represents a stalemate", func() {
@@ -242,15 +401,49 @@ var _ = Describe("Board", func() {
 					Expect(board.Result).To(Equal(chess.BOARD_RESULT_DRAW_BY_INSUFFICIENT_MATERIAL))
 				})
 			})
-			When("the FEN represents a draw by fifty move rule board", func() {
+			When("the FEN represents a draw by seventy five move rule board", func() {
 				It("returns a terminal draw board", func() {
-					board, err := chess.BoardFromFEN("k7/r7/8/8/8/4KQ2/8/8 w - - 50 1")
+					board, err := chess.BoardFromFEN("k7/r7/8/8/8/4KQ2/8/8 w - - 150 1")
 					Expect(err).ToNot(HaveOccurred())
-					Expect(board.Result).To(Equal(chess.BOARD_RESULT_DRAW_BY_FIFTY_MOVE_RULE))
+					Expect(board.Result).To(Equal(chess.BOARD_RESULT_DRAW_BY_SEVENTY_FIVE_MOVE_RULE))
+				})
+			})
+			When("the FEN represents a board where the fifty move rule can be claimed", func() {
+				It("returns a board in progress", func() {
+					board, err := chess.BoardFromFEN("k7/r7/8/8/8/4KQ2/8/8 w - - 100 1")
+					Expect(err).ToNot(HaveOccurred())
+					Expect(board.Result).To(Equal(chess.BOARD_RESULT_IN_PROGRESS))
 				})
 			})
 		})
 		When("the FEN is not valid", func() {
+			DescribeTable("the position cannot occur in a game and is read strictly", func(fen string, expErr error) {
+				_, err := chess.BoardFromFENWithValidation(fen, chess.FEN_VALIDATION_STRICT)
+				Expect(errors.Is(err, expErr)).To(BeTrue())
+			},
+				Entry("no black king", "8/8/8/8/8/8/8/4K3 w - - 0 1", chess.ErrNoKing),
+				Entry("two white kings", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBKKBNR w KQkq - 0 1", chess.ErrTooManyKings),
+				Entry("nine pawns", "4k3/8/8/8/7P/8/PPPPPPPP/4K3 w - - 0 1", chess.ErrTooManyPieces),
+				Entry("seventeen pieces", "4k3/8/8/8/8/QQQQQQQQ/PPPPPPPP/4K3 w - - 0 1", chess.ErrTooManyPieces),
+				Entry("pawn on the eighth rank", "P3k3/8/8/8/8/8/8/4K3 w - - 0 1", chess.ErrPawnOnBackRank),
+				Entry("side not to move in check", "5k2/8/8/8/8/8/8/4KR2 w - - 0 1", chess.ErrOpponentInCheck),
+				Entry("kings next to each other", "8/8/8/8/8/8/3k4/4K3 w - - 0 1", chess.ErrOpponentInCheck),
+				Entry("castle rights without a rook", "4k3/8/8/8/8/8/8/4K3 w K - 0 1", chess.ErrInvalidCastleRights),
+				Entry("castle rights with a moved king", "4k3/8/8/8/8/8/3K4/7R w K - 0 1", chess.ErrInvalidCastleRights),
+				Entry("en passant square on the wrong rank", "4k3/8/8/8/4P3/8/8/4K3 w - e3 0 1", chess.ErrInvalidEnPassantSquare),
+				Entry("en passant square without a pawn", "4k3/8/8/8/8/8/8/4K3 b - e3 0 1", chess.ErrInvalidEnPassantSquare),
+			)
+			When("the FEN is read leniently", func() {
+				It("drops castle rights and en passant squares that don't fit the position", func() {
+					board, err := chess.BoardFromFENWithValidation("4k2r/8/8/8/8/8/8/4K3 b KQk e3 0 1", chess.FEN_VALIDATION_LENIENT)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(board.ToFEN()).To(Equal("4k2r/8/8/8/8/8/8/4K3 b k - 0 1"))
+				})
+				It("still rejects positions it cannot repair", func() {
+					_, err := chess.BoardFromFENWithValidation("8/8/8/8/8/8/8/4K3 w - - 0 1", chess.FEN_VALIDATION_LENIENT)
+					Expect(errors.Is(err, chess.ErrNoKing)).To(BeTrue())
+				})
+			})
 			Context("the FEN does not have the correct amount of segments", func() {
 				It("returns an error", func() {
 					invalidFEN := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0"
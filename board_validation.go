@@ -0,0 +1,171 @@
+package chess
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned when a position cannot occur in a game of chess. They are wrapped with details about the
+// position, so match them with errors.Is.
+var (
+	ErrNoKing                 = errors.New("side has no king")
+	ErrTooManyKings           = errors.New("side has more than one king")
+	ErrTooManyPieces          = errors.New("side has too many pieces")
+	ErrPawnOnBackRank         = errors.New("pawn on the first or eighth rank")
+	ErrOpponentInCheck        = errors.New("side not to move is in check")
+	ErrInvalidCastleRights    = errors.New("castle rights without the king and rook on their home squares")
+	ErrInvalidEnPassantSquare = errors.New("en passant square without a pawn that just moved two squares")
+)
+
+type FENValidation string
+
+const (
+	// FEN_VALIDATION_STRICT rejects any position that cannot occur in a game of chess
+	FEN_VALIDATION_STRICT FENValidation = "strict"
+	// FEN_VALIDATION_LENIENT drops castle rights and en passant squares that don't fit the position, rejecting
+	// only positions that cannot be repaired that way
+	FEN_VALIDATION_LENIENT FENValidation = "lenient"
+	// FEN_VALIDATION_NONE reads the position as given, e.g. for composed positions that only exercise part of
+	// the rules
+	FEN_VALIDATION_NONE FENValidation = "none"
+)
+
+// Validate reports the first reason the board cannot occur in a game of chess, or nil if it can
+func (board *Board) Validate() error {
+	return validateBoard(NewBoardBuilder().FromBoard(board), false)
+}
+
+func validateBoard(boardBuilder *BoardBuilder, shouldRepair bool) error {
+	board := boardBuilder.board
+	for _, isWhite := range []bool{true, false} {
+		if err := validatePieceCounts(board, isWhite); err != nil {
+			return err
+		}
+	}
+	for file := uint8(1); file <= 8; file++ {
+		for _, rank := range []uint8{1, 8} {
+			square := &Square{rank, file}
+			if board.GetPieceOnSquare(square).IsPawn() {
+				return fmt.Errorf("%w, got pawn on %s", ErrPawnOnBackRank, square.ToAlgebraicCoords())
+			}
+		}
+	}
+	if isKingAttacked(board, !board.IsWhiteTurn) {
+		return fmt.Errorf("%w, %s king is attacked", ErrOpponentInCheck, sideName(!board.IsWhiteTurn))
+	}
+
+	for _, isWhite := range []bool{true, false} {
+		for _, isKingside := range []bool{true, false} {
+			if !board.hasCastleRights(isWhite, isKingside) || hasCastlePieces(board, isWhite, isKingside) {
+				continue
+			}
+			if !shouldRepair {
+				return fmt.Errorf("%w, %s cannot castle %s", ErrInvalidCastleRights, sideName(isWhite), castleSideName(isKingside))
+			}
+			clearCastleRights(boardBuilder, isWhite, isKingside)
+		}
+	}
+
+	if board.OptEnPassantSquare != nil && !hasEnPassantPawn(board) {
+		if !shouldRepair {
+			return fmt.Errorf("%w, got %s", ErrInvalidEnPassantSquare, board.OptEnPassantSquare.ToAlgebraicCoords())
+		}
+		boardBuilder.WithEnPassantSquare(nil)
+	}
+	return nil
+}
+
+func validatePieceCounts(board *Board, isWhite bool) error {
+	var kingCnt, pawnCnt, pieceCnt int
+	for _, row := range board.Pieces {
+		for _, piece := range row {
+			if piece == EMPTY || piece.IsWhite() != isWhite {
+				continue
+			}
+			pieceCnt++
+			if piece.IsKing() {
+				kingCnt++
+			} else if piece.IsPawn() {
+				pawnCnt++
+			}
+		}
+	}
+	if kingCnt == 0 {
+		return fmt.Errorf("%w, %s has none", ErrNoKing, sideName(isWhite))
+	}
+	if kingCnt > 1 {
+		return fmt.Errorf("%w, %s has %d", ErrTooManyKings, sideName(isWhite), kingCnt)
+	}
+	if pieceCnt > 16 {
+		return fmt.Errorf("%w, %s has %d pieces", ErrTooManyPieces, sideName(isWhite), pieceCnt)
+	}
+	if pawnCnt > 8 {
+		return fmt.Errorf("%w, %s has %d pawns", ErrTooManyPieces, sideName(isWhite), pawnCnt)
+	}
+	return nil
+}
+
+// isKingAttacked reports whether the king is in check, counting the enemy king standing next to it
+func isKingAttacked(board *Board, isWhiteKing bool) bool {
+	if len(GetCheckingSquares(board, isWhiteKing)) > 0 {
+		return true
+	}
+	kingSquare := board.GetKingSquare(isWhiteKing)
+	enemyKingSquare := board.GetKingSquare(!isWhiteKing)
+	rankDiff := int(kingSquare.Rank) - int(enemyKingSquare.Rank)
+	fileDiff := int(kingSquare.File) - int(enemyKingSquare.File)
+	return rankDiff >= -1 && rankDiff <= 1 && fileDiff >= -1 && fileDiff <= 1
+}
+
+// hasCastlePieces reports whether the king and the castling rook stand where castling rights need them to be
+func hasCastlePieces(board *Board, isWhite bool, isKingside bool) bool {
+	kingSquare := board.GetKingSquare(isWhite)
+	if kingSquare.Rank != backRank(isWhite) {
+		return false
+	}
+	if !board.IsChess960 && kingSquare.File != 5 {
+		return false
+	}
+	rookSquare := board.CastleRookSquare(isWhite, isKingside)
+	if isKingside != (rookSquare.File > kingSquare.File) {
+		return false
+	}
+	rook := WHITE_ROOK
+	if !isWhite {
+		rook = BLACK_ROOK
+	}
+	return board.GetPieceOnSquare(rookSquare) == rook
+}
+
+func clearCastleRights(boardBuilder *BoardBuilder, isWhite bool, isKingside bool) {
+	if isWhite && isKingside {
+		boardBuilder.WithCanWhiteCastleKingside(false)
+	} else if isWhite {
+		boardBuilder.WithCanWhiteCastleQueenside(false)
+	} else if isKingside {
+		boardBuilder.WithCanBlackCastleKingside(false)
+	} else {
+		boardBuilder.WithCanBlackCastleQueenside(false)
+	}
+	boardBuilder.WithCastleRookFile(isWhite, isKingside, 0)
+}
+
+// hasEnPassantPawn reports whether the en passant square was just passed over by a pawn of the side not to move
+func hasEnPassantPawn(board *Board) bool {
+	epSquare := board.OptEnPassantSquare
+	pawn, pawnRank, epRank, originRank := BLACK_PAWN, uint8(5), uint8(6), uint8(7)
+	if !board.IsWhiteTurn {
+		pawn, pawnRank, epRank, originRank = WHITE_PAWN, 4, 3, 2
+	}
+	return epSquare.Rank == epRank &&
+		board.GetPieceOnSquare(epSquare) == EMPTY &&
+		board.GetPieceOnSquare(&Square{originRank, epSquare.File}) == EMPTY &&
+		board.GetPieceOnSquare(&Square{pawnRank, epSquare.File}) == pawn
+}
+
+func castleSideName(isKingside bool) string {
+	if isKingside {
+		return "kingside"
+	}
+	return "queenside"
+}
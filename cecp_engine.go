@@ -0,0 +1,379 @@
+package chess
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CECP_MATE_SCORE is added to the moves to mate in thinking output, which CECP has no separate mate score for
+const CECP_MATE_SCORE = 100000
+
+// CECPEngine speaks the Chess Engine Communication Protocol (protover 2) used by XBoard and WinBoard, handing
+// searches to the same Searcher a UCIEngine uses. Unlike UCI, the engine keeps the game itself: it plays the
+// side the GUI assigns it and makes its own moves on the board.
+type CECPEngine struct {
+	Name string
+
+	searcher Searcher
+	board    *Board
+	// history holds the boards before each move, for undo
+	history       []*Board
+	isForce       bool
+	isEngineWhite bool
+	isPost        bool
+	isChess960    bool
+
+	// level is the time control: moves per control (0 for the whole game), base time and increment
+	levelMoves     int
+	levelBase      time.Duration
+	levelIncrement time.Duration
+	moveTime       time.Duration
+	depth          int
+	engineTime     time.Duration
+	opponentTime   time.Duration
+
+	outMu        sync.Mutex
+	out          io.Writer
+	cancelSearch context.CancelFunc
+	abortSearch  chan struct{}
+	searchDone   chan struct{}
+}
+
+func NewCECPEngine(name string, searcher Searcher) *CECPEngine {
+	engine := &CECPEngine{
+		Name:     name,
+		searcher: searcher,
+	}
+	engine.newGame()
+	return engine
+}
+
+// Run reads commands from in and writes replies to out until "quit" or the end of the input
+func (engine *CECPEngine) Run(in io.Reader, out io.Writer) error {
+	engine.out = out
+	defer engine.stopSearch(false)
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "quit" {
+			return nil
+		}
+		if err := engine.handleCommand(fields[0], fields[1:]); err != nil {
+			engine.writeLine(err.Error())
+		}
+	}
+	return scanner.Err()
+}
+
+func (engine *CECPEngine) handleCommand(command string, args []string) error {
+	switch command {
+	case "protover":
+		engine.writeLine(fmt.Sprintf("feature myname=\"%s\" setboard=1 usermove=1 ping=1 playother=1 colors=0 "+
+			"sigint=0 sigterm=0 analyze=0 variants=\"normal,fischerandom\"", engine.Name))
+		engine.writeLine("feature done=1")
+	case "new":
+		engine.stopSearch(false)
+		engine.newGame()
+		if newGameSearcher, ok := engine.searcher.(NewGameSearcher); ok {
+			newGameSearcher.NewGame()
+		}
+	case "variant":
+		if len(args) == 0 || (args[0] != "normal" && args[0] != "fischerandom") {
+			return fmt.Errorf("Error (unsupported variant): %s", strings.Join(args, " "))
+		}
+		engine.stopSearch(false)
+		engine.isChess960 = args[0] == "fischerandom"
+		engine.board = NewBoardBuilder().FromBoard(engine.board).WithIsChess960(engine.isChess960).Build()
+	case "force":
+		engine.stopSearch(false)
+		engine.isForce = true
+	case "go":
+		engine.stopSearch(false)
+		engine.isForce = false
+		engine.isEngineWhite = engine.board.IsWhiteTurn
+		engine.startSearch()
+	case "playother":
+		engine.stopSearch(false)
+		engine.isForce = false
+		engine.isEngineWhite = !engine.board.IsWhiteTurn
+	case "usermove":
+		if len(args) != 1 {
+			return fmt.Errorf("Error (usermove needs a move): %s", strings.Join(args, " "))
+		}
+		return engine.userMove(args[0])
+	case "setboard":
+		engine.stopSearch(false)
+		board, err := BoardFromFEN(strings.Join(args, " "))
+		if err != nil {
+			return fmt.Errorf("tellusererror Illegal position: %s", err)
+		}
+		if engine.isChess960 {
+			board = NewBoardBuilder().FromBoard(board).WithIsChess960(true).Build()
+		}
+		engine.board = board
+		engine.history = nil
+	case "undo", "remove":
+		engine.stopSearch(false)
+		undoCount := 1
+		if command == "remove" {
+			undoCount = 2
+		}
+		if len(engine.history) < undoCount {
+			return fmt.Errorf("Error (no move to undo): %s", command)
+		}
+		engine.board = engine.history[len(engine.history)-undoCount]
+		engine.history = engine.history[:len(engine.history)-undoCount]
+	case "?":
+		engine.stopSearch(true)
+	case "result":
+		engine.stopSearch(false)
+		engine.isForce = true
+	case "level":
+		return engine.setLevel(args)
+	case "st":
+		seconds, err := parseCECPNumber(command, args)
+		if err != nil {
+			return err
+		}
+		engine.moveTime = time.Duration(seconds * float64(time.Second))
+	case "sd":
+		depth, err := parseCECPNumber(command, args)
+		if err != nil {
+			return err
+		}
+		engine.depth = int(depth)
+	case "time", "otim":
+		centiseconds, err := parseCECPNumber(command, args)
+		if err != nil {
+			return err
+		}
+		if command == "time" {
+			engine.engineTime = time.Duration(centiseconds) * 10 * time.Millisecond
+		} else {
+			engine.opponentTime = time.Duration(centiseconds) * 10 * time.Millisecond
+		}
+	case "ping":
+		engine.writeLine("pong " + strings.Join(args, " "))
+	case "post":
+		engine.isPost = true
+	case "nopost":
+		engine.isPost = false
+	case "xboard", "accepted", "rejected", "random", "hard", "easy", "computer", "name", "rating", "ics", "draw",
+		"random960":
+	default:
+		return fmt.Errorf("Error (unknown command): %s", command)
+	}
+	return nil
+}
+
+// newGame sets up the standard position with the engine playing black, as after "new"
+func (engine *CECPEngine) newGame() {
+	engine.board = GetInitBoard()
+	engine.history = nil
+	engine.isForce = false
+	engine.isChess960 = false
+	engine.isEngineWhite = false
+	engine.moveTime = 0
+	engine.depth = 0
+	engine.engineTime = 0
+	engine.opponentTime = 0
+}
+
+// userMove plays the opponent's move, given in coordinate notation or SAN, and answers it unless in force mode
+func (engine *CECPEngine) userMove(notation string) error {
+	engine.stopSearch(false)
+	move, err := MoveFromLongAlgebraic(notation, engine.board)
+	if err != nil {
+		var sanErr error
+		move, sanErr = MoveFromAlgebraic(notation, engine.board)
+		if sanErr != nil {
+			return fmt.Errorf("Illegal move: %s", notation)
+		}
+	}
+	engine.playMove(move)
+	if !engine.isForce && engine.board.IsWhiteTurn == engine.isEngineWhite {
+		engine.startSearch()
+	}
+	return nil
+}
+
+func (engine *CECPEngine) playMove(move *Move) {
+	engine.history = append(engine.history, engine.board)
+	engine.board = GetBoardFromMove(engine.board, move)
+}
+
+// setLevel reads "level <moves per control> <base minutes[:seconds]> <increment seconds>"
+func (engine *CECPEngine) setLevel(args []string) error {
+	if len(args) != 3 {
+		return fmt.Errorf("Error (level needs 3 values): %s", strings.Join(args, " "))
+	}
+	levelMoves, movesErr := strconv.Atoi(args[0])
+	if movesErr != nil {
+		return fmt.Errorf("Error (invalid moves per control): %s", args[0])
+	}
+	minutesStr, secondsStr, hasSeconds := strings.Cut(args[1], ":")
+	minutes, minutesErr := strconv.Atoi(minutesStr)
+	seconds := 0
+	var secondsErr error
+	if hasSeconds {
+		seconds, secondsErr = strconv.Atoi(secondsStr)
+	}
+	if minutesErr != nil || secondsErr != nil {
+		return fmt.Errorf("Error (invalid base time): %s", args[1])
+	}
+	increment, incrementErr := strconv.ParseFloat(args[2], 64)
+	if incrementErr != nil {
+		return fmt.Errorf("Error (invalid increment): %s", args[2])
+	}
+	engine.levelMoves = levelMoves
+	engine.levelBase = time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second
+	engine.levelIncrement = time.Duration(increment * float64(time.Second))
+	engine.moveTime = 0
+	return nil
+}
+
+func parseCECPNumber(command string, args []string) (float64, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("Error (%s needs a value): %s", command, strings.Join(args, " "))
+	}
+	value, err := strconv.ParseFloat(args[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("Error (invalid value): %s %s", command, args[0])
+	}
+	return value, nil
+}
+
+// searchLimits converts the time control and the clocks into the limits of a search for the side to move
+func (engine *CECPEngine) searchLimits() *SearchLimits {
+	limits := &SearchLimits{Depth: engine.depth, MoveTime: engine.moveTime}
+	// the clocks start at the level's base time until the GUI sends "time" and "otim"
+	engineTime, opponentTime := engine.engineTime, engine.opponentTime
+	if engineTime == 0 {
+		engineTime = engine.levelBase
+	}
+	if opponentTime == 0 {
+		opponentTime = engine.levelBase
+	}
+	if engine.moveTime > 0 || engineTime == 0 {
+		return limits
+	}
+	if engine.board.IsWhiteTurn {
+		limits.WhiteTime, limits.BlackTime = engineTime, opponentTime
+	} else {
+		limits.WhiteTime, limits.BlackTime = opponentTime, engineTime
+	}
+	limits.WhiteIncrement, limits.BlackIncrement = engine.levelIncrement, engine.levelIncrement
+	if engine.levelMoves > 0 {
+		movesPlayed := int(engine.board.FullMoveCount) - 1
+		limits.MovesToGo = engine.levelMoves - movesPlayed%engine.levelMoves
+	}
+	return limits
+}
+
+func (engine *CECPEngine) startSearch() {
+	if engine.board.Result != BOARD_RESULT_IN_PROGRESS {
+		return
+	}
+	limits := engine.searchLimits()
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if limits.MoveTime > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), limits.MoveTime)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	abort := make(chan struct{})
+	done := make(chan struct{})
+	engine.cancelSearch = cancel
+	engine.abortSearch = abort
+	engine.searchDone = done
+	// boards memoize derived state on first read, so the searcher gets its own copy
+	board := NewBoardBuilder().FromBoard(engine.board).Build()
+	isPost := engine.isPost
+
+	go func() {
+		defer close(done)
+		onInfo := func(info *SearchInfo) {
+			if isPost {
+				engine.writeThinking(board, info)
+			}
+		}
+		move, err := engine.searcher.Search(ctx, board, limits, onInfo)
+		select {
+		case <-abort:
+			return
+		default:
+		}
+		if err != nil {
+			engine.writeLine("Error (search failed): " + err.Error())
+			return
+		}
+		if move == nil {
+			return
+		}
+		engine.writeLine("move " + engine.moveNotation(board, move))
+		engine.playMove(move)
+		if engine.board.Result != BOARD_RESULT_IN_PROGRESS {
+			outcome := GameOutcomeFromBoardResult(engine.board.Result)
+			engine.writeLine(fmt.Sprintf("%s {%s}", outcome.ToPGNResult(), strings.ReplaceAll(string(outcome.Reason), "_", " ")))
+		}
+	}()
+}
+
+// stopSearch ends the running search and waits for it. With shouldPlay, as for "?", the engine plays the best
+// move found so far; otherwise the search is abandoned because the position changed under it.
+func (engine *CECPEngine) stopSearch(shouldPlay bool) {
+	if engine.cancelSearch == nil {
+		return
+	}
+	if !shouldPlay {
+		close(engine.abortSearch)
+	}
+	engine.cancelSearch()
+	<-engine.searchDone
+	engine.cancelSearch = nil
+	engine.abortSearch = nil
+	engine.searchDone = nil
+}
+
+// moveNotation writes moves in coordinate notation, except Chess960 castling, which CECP writes as O-O or
+// O-O-O
+func (engine *CECPEngine) moveNotation(board *Board, move *Move) string {
+	if board.IsChess960 && move.IsCastlesOn(board) {
+		return strings.TrimRight(move.ToAlgebraic(board), "+#")
+	}
+	return move.ToLongAlgebraic()
+}
+
+// writeThinking writes a search's progress as "<depth> <score> <centiseconds> <nodes> <pv>"
+func (engine *CECPEngine) writeThinking(board *Board, info *SearchInfo) {
+	score := info.Score
+	if info.IsMate && score > 0 {
+		score += CECP_MATE_SCORE
+	} else if info.IsMate {
+		score -= CECP_MATE_SCORE
+	}
+	pvStrs := make([]string, 0, len(info.PV))
+	pvBoard := board
+	for _, move := range info.PV {
+		pvStrs = append(pvStrs, move.ToAlgebraic(pvBoard))
+		pvBoard = GetBoardFromMove(pvBoard, move)
+	}
+	engine.writeLine(fmt.Sprintf("%d %d %d %d %s", info.Depth, score, info.Time.Milliseconds()/10, info.Nodes,
+		strings.Join(pvStrs, " ")))
+}
+
+func (engine *CECPEngine) writeLine(line string) {
+	engine.outMu.Lock()
+	defer engine.outMu.Unlock()
+	fmt.Fprintln(engine.out, line)
+}
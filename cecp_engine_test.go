@@ -0,0 +1,191 @@
+package chess_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"time"
+
+	. "github.com/CameronHonis/chess"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CECPEngine", func() {
+	var searcher *firstMoveSearcher
+	var toEngine *io.PipeWriter
+	var lines chan string
+	send := func(command string) {
+		_, err := fmt.Fprintln(toEngine, command)
+		Expect(err).ToNot(HaveOccurred())
+	}
+	BeforeEach(func() {
+		searcher = &firstMoveSearcher{options: make(map[string]string)}
+		engine := NewCECPEngine("TestEngine", searcher)
+		engineIn, engineInWriter := io.Pipe()
+		engineOutReader, engineOut := io.Pipe()
+		toEngine = engineInWriter
+		engineLines := make(chan string, 100)
+		runErr := make(chan error, 1)
+		lines = engineLines
+		go func() {
+			runErr <- engine.Run(engineIn, engineOut)
+			engineOut.Close()
+		}()
+		go func() {
+			scanner := bufio.NewScanner(engineOutReader)
+			for scanner.Scan() {
+				engineLines <- scanner.Text()
+			}
+			close(engineLines)
+		}()
+		DeferCleanup(func() {
+			send("quit")
+			Eventually(runErr).Should(Receive(BeNil()))
+		})
+		send("xboard")
+		send("protover 2")
+		Eventually(lines, time.Second).Should(Receive(HaveSuffix("variants=\"normal,fischerandom\"")))
+		Eventually(lines, time.Second).Should(Receive(Equal("feature done=1")))
+	})
+	expectLine := func(expLine string) {
+		EventuallyWithOffset(1, lines, time.Second).Should(Receive(Equal(expLine)))
+	}
+	firstMoveFrom := func(fen string) string {
+		board, _ := BoardFromFEN(fen)
+		moves, _ := GetLegalMoves(board)
+		return moves[0].ToLongAlgebraic()
+	}
+
+	It("answers the user's moves as black after new", func() {
+		send("new")
+		send("usermove e2e4")
+		expectLine("move " + firstMoveFrom("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"))
+		Expect(searcher.boards).To(HaveLen(1))
+	})
+	It("plays the side to move on go after force", func() {
+		send("new")
+		send("force")
+		send("usermove e2e4")
+		send("usermove e7e5")
+		send("ping 1")
+		expectLine("pong 1")
+		Expect(searcher.boards).To(BeEmpty())
+		send("go")
+		expectLine("move b1a3")
+		Expect(searcher.boards[0].ToFEN()).To(Equal("rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2"))
+		send("usermove g8f6")
+		expectLine("move " + firstMoveFrom("rnbqkb1r/pppp1ppp/5n2/4p3/4P3/N7/PPPP1PPP/R1BQKBNR w KQkq - 2 3"))
+	})
+	It("plays the other side on playother", func() {
+		send("new")
+		send("force")
+		send("usermove e2e4")
+		send("playother")
+		send("ping 1")
+		expectLine("pong 1")
+		Expect(searcher.boards).To(BeEmpty())
+		send("usermove e7e5")
+		expectLine("move b1a3")
+	})
+	It("sets up a position with setboard", func() {
+		send("new")
+		send("force")
+		send("setboard 7k/8/8/8/8/8/R7/7K b - - 0 1")
+		send("go")
+		expectLine("move h8g8")
+	})
+	It("takes moves back with undo and remove", func() {
+		send("new")
+		send("force")
+		send("usermove e2e4")
+		send("usermove e7e5")
+		send("usermove g1f3")
+		send("undo")
+		send("remove")
+		send("go")
+		expectLine("move b1a3")
+		Expect(searcher.boards[0].IsInitBoard()).To(BeTrue())
+	})
+	It("converts the time control and clocks into search limits", func() {
+		send("new")
+		send("level 40 5 2")
+		send("time 30000")
+		send("otim 29000")
+		send("usermove e2e4")
+		Eventually(lines, time.Second).Should(Receive(HavePrefix("move")))
+		Expect(searcher.limits[0]).To(Equal(&SearchLimits{
+			WhiteTime:      290 * time.Second,
+			BlackTime:      300 * time.Second,
+			WhiteIncrement: 2 * time.Second,
+			BlackIncrement: 2 * time.Second,
+			MovesToGo:      40,
+		}))
+	})
+	It("reads a base time with seconds and passes on the search depth", func() {
+		send("new")
+		send("level 0 0:30 0")
+		send("sd 4")
+		send("usermove e2e4")
+		Eventually(lines, time.Second).Should(Receive(HavePrefix("move")))
+		Expect(searcher.limits[0]).To(Equal(&SearchLimits{
+			Depth:     4,
+			WhiteTime: 30 * time.Second,
+			BlackTime: 30 * time.Second,
+		}))
+	})
+	It("moves at once on ?", func() {
+		send("new")
+		send("st 30")
+		send("go")
+		Consistently(lines, 50*time.Millisecond).ShouldNot(Receive())
+		send("?")
+		expectLine("move b1a3")
+	})
+	It("abandons the search when the game ends", func() {
+		send("new")
+		send("st 30")
+		send("go")
+		send("result 1-0 {White resigns}")
+		send("ping 2")
+		expectLine("pong 2")
+	})
+	It("posts its thinking in SAN", func() {
+		send("new")
+		send("post")
+		send("go")
+		expectLine("1 12 0 20 Na3")
+		expectLine("move b1a3")
+	})
+	It("announces the result when its move ends the game", func() {
+		send("new")
+		send("force")
+		send("setboard k7/2K5/1R6/8/8/8/8/8 w - - 0 1")
+		send("go")
+		expectLine("move b6b7")
+		expectLine("1/2-1/2 {stalemate}")
+	})
+	It("rejects illegal moves and unknown commands", func() {
+		send("new")
+		send("usermove e2e5")
+		expectLine("Illegal move: e2e5")
+		send("hello")
+		expectLine("Error (unknown command): hello")
+	})
+	It("reads Chess960 castling as O-O", func() {
+		send("new")
+		send("variant fischerandom")
+		send("force")
+		send("setboard bqnbrkrn/pppppppp/8/8/8/8/PPPPPPPP/BQNBRKRN w GEge - 0 1")
+		send("usermove g2g3")
+		send("usermove g7g6")
+		send("usermove O-O")
+		send("ping 4")
+		expectLine("pong 4")
+		send("go")
+		Eventually(lines, time.Second).Should(Receive(HavePrefix("move")))
+		Expect(searcher.boards[0].IsChess960).To(BeTrue())
+		Expect(searcher.boards[0].GetPieceOnSquare(&Square{1, 7})).To(Equal(WHITE_KING))
+		Expect(searcher.boards[0].GetPieceOnSquare(&Square{1, 6})).To(Equal(WHITE_ROOK))
+	})
+})
@@ -0,0 +1,109 @@
+package chess
+
+import "fmt"
+
+const CHESS960_POSITION_COUNT = 960
+
+// CHESS960_STANDARD_POSITION_INDEX is the index of the standard chess starting position
+const CHESS960_STANDARD_POSITION_INDEX = 518
+
+// chess960KnightFiles lists the placements of the two knights among the five files left after placing the
+// bishops and queen, in the order of the standard numbering scheme
+var chess960KnightFiles = [10][2]int{{0, 1}, {0, 2}, {0, 3}, {0, 4}, {1, 2}, {1, 3}, {1, 4}, {2, 3}, {2, 4}, {3, 4}}
+
+// Chess960StartBoard returns the Chess960 starting position with the given index in [0, 960), using the standard
+// numbering scheme in which index 518 is the standard chess starting position
+func Chess960StartBoard(index int) (*Board, error) {
+	if index < 0 || index >= CHESS960_POSITION_COUNT {
+		return nil, fmt.Errorf("invalid Chess960 position index, expected [0, %d), got %d", CHESS960_POSITION_COUNT, index)
+	}
+	var backRankFiles [8]Piece
+	n := index
+	// light squared bishop on b, d, f or h, then dark squared bishop on a, c, e or g
+	backRankFiles[n%4*2+1] = WHITE_BISHOP
+	n /= 4
+	backRankFiles[n%4*2] = WHITE_BISHOP
+	n /= 4
+	emptyFiles := chess960EmptyFiles(backRankFiles)
+	backRankFiles[emptyFiles[n%6]] = WHITE_QUEEN
+	n /= 6
+	emptyFiles = chess960EmptyFiles(backRankFiles)
+	for _, knightIdx := range chess960KnightFiles[n] {
+		backRankFiles[emptyFiles[knightIdx]] = WHITE_KNIGHT
+	}
+	emptyFiles = chess960EmptyFiles(backRankFiles)
+	backRankFiles[emptyFiles[0]] = WHITE_ROOK
+	backRankFiles[emptyFiles[1]] = WHITE_KING
+	backRankFiles[emptyFiles[2]] = WHITE_ROOK
+
+	boardBuilder := NewBoardBuilder()
+	for fileIdx, piece := range backRankFiles {
+		file := uint8(fileIdx + 1)
+		boardBuilder.WithPiece(piece, &Square{1, file})
+		boardBuilder.WithPiece(WHITE_PAWN, &Square{2, file})
+		boardBuilder.WithPiece(BLACK_PAWN, &Square{7, file})
+		boardBuilder.WithPiece(blackPieceOf(piece), &Square{8, file})
+	}
+	boardBuilder.WithIsWhiteTurn(true).
+		WithCanWhiteCastleKingside(true).
+		WithCanWhiteCastleQueenside(true).
+		WithCanBlackCastleKingside(true).
+		WithCanBlackCastleQueenside(true).
+		WithFullMoveCount(1).
+		WithResult(BOARD_RESULT_IN_PROGRESS).
+		WithIsChess960(true)
+	for _, isWhite := range []bool{true, false} {
+		boardBuilder.WithCastleRookFile(isWhite, false, uint8(emptyFiles[0]+1))
+		boardBuilder.WithCastleRookFile(isWhite, true, uint8(emptyFiles[2]+1))
+	}
+	board := boardBuilder.Build()
+	boardBuilder.WithKeyCount(board.ZobristKey(), 1)
+	return boardBuilder.Build(), nil
+}
+
+// Chess960BoardFromFEN reads a FEN for a Chess960 game. Castling rights may be given in X-FEN or Shredder-FEN;
+// KQkq refer to the outermost rook on each side of the king.
+func Chess960BoardFromFEN(fen string) (*Board, error) {
+	board, err := BoardFromFEN(fen)
+	if err != nil {
+		return nil, err
+	}
+	return NewBoardBuilder().FromBoard(board).WithIsChess960(true).Build(), nil
+}
+
+func outermostRookFile(board *Board, isWhite bool, isKingside bool) (uint8, bool) {
+	rank := backRank(isWhite)
+	kingFile := board.GetKingSquare(isWhite).File
+	rook := WHITE_ROOK
+	if !isWhite {
+		rook = BLACK_ROOK
+	}
+	if isKingside {
+		for file := uint8(8); file > kingFile; file-- {
+			if board.GetPieceOnSquare(&Square{rank, file}) == rook {
+				return file, true
+			}
+		}
+	} else {
+		for file := uint8(1); file < kingFile; file++ {
+			if board.GetPieceOnSquare(&Square{rank, file}) == rook {
+				return file, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func chess960EmptyFiles(backRankFiles [8]Piece) []int {
+	emptyFiles := make([]int, 0, 8)
+	for fileIdx, piece := range backRankFiles {
+		if piece == EMPTY {
+			emptyFiles = append(emptyFiles, fileIdx)
+		}
+	}
+	return emptyFiles
+}
+
+func blackPieceOf(whitePiece Piece) Piece {
+	return whitePiece + BLACK_PAWN - WHITE_PAWN
+}
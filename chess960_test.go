@@ -0,0 +1,176 @@
+package chess_test
+
+import (
+	. "github.com/CameronHonis/chess"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Chess960", func() {
+	Describe("#Chess960StartBoard", func() {
+		It("numbers the standard starting position 518", func() {
+			board, err := Chess960StartBoard(CHESS960_STANDARD_POSITION_INDEX)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(board.Pieces).To(Equal(GetInitBoard().Pieces))
+			Expect(board.IsChess960).To(BeTrue())
+		})
+		It("follows the standard numbering scheme", func() {
+			board, err := Chess960StartBoard(0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(board.ToFEN()).To(Equal("bbqnnrkr/pppppppp/8/8/8/8/PPPPPPPP/BBQNNRKR w KQkq - 0 1"))
+			Expect(board.CastleRookSquare(true, false)).To(Equal(&Square{1, 6}))
+			Expect(board.CastleRookSquare(false, true)).To(Equal(&Square{8, 8}))
+		})
+		It("generates 960 distinct valid positions", func() {
+			seenBackRanks := make(map[string]bool)
+			for idx := 0; idx < CHESS960_POSITION_COUNT; idx++ {
+				board, err := Chess960StartBoard(idx)
+				Expect(err).ToNot(HaveOccurred())
+				mat := board.ComputeMaterialCount()
+				Expect(mat.WhiteLightBishopCount).To(Equal(uint8(1)))
+				Expect(mat.WhiteDarkBishopCount).To(Equal(uint8(1)))
+				kingFile := board.GetKingSquare(true).File
+				Expect(board.CastleRookSquare(true, false).File).To(BeNumerically("<", kingFile))
+				Expect(board.CastleRookSquare(true, true).File).To(BeNumerically(">", kingFile))
+				seenBackRanks[board.ToMiniFEN()] = true
+			}
+			Expect(seenBackRanks).To(HaveLen(CHESS960_POSITION_COUNT))
+		})
+		It("rejects indexes out of range", func() {
+			_, err := Chess960StartBoard(CHESS960_POSITION_COUNT)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("castling", func() {
+		When("the king castles kingside from the f-file", func() {
+			var board *Board
+			BeforeEach(func() {
+				board, _ = Chess960BoardFromFEN("4k3/8/8/8/8/8/8/RN3K1R w KQ - 0 1")
+			})
+			It("is written as the king moving onto the rook", func() {
+				move, err := MoveFromAlgebraic("O-O", board)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(move.ToLongAlgebraic()).To(Equal("f1h1"))
+				Expect(move.ToAlgebraic(board)).To(Equal("O-O"))
+			})
+			It("lands the king on g1 and the rook on f1", func() {
+				move, _ := MoveFromLongAlgebraic("f1h1", board)
+				nextBoard := GetBoardFromMove(board, move)
+				Expect(nextBoard.ToFEN()).To(Equal("4k3/8/8/8/8/8/8/RN3RK1 b - - 0 1"))
+			})
+			It("is not confused with the king stepping to g1", func() {
+				move, err := MoveFromAlgebraic("Kg1", board)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(move.IsCastlesOn(board)).To(BeFalse())
+				Expect(GetBoardFromMove(board, move).ToFEN()).To(HavePrefix("4k3/8/8/8/8/8/8/RN4KR b - -"))
+			})
+		})
+		When("a piece stands between the rook and its landing square", func() {
+			It("does not allow castling", func() {
+				board, _ := Chess960BoardFromFEN("4k3/8/8/8/8/8/8/RN3K1R w KQ - 0 1")
+				_, err := MoveFromAlgebraic("O-O-O", board)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+		When("the king does not move", func() {
+			It("only moves the rook", func() {
+				board, _ := Chess960BoardFromFEN("4k3/8/8/8/8/8/8/1R4KR w KQ - 0 1")
+				move, err := MoveFromAlgebraic("O-O", board)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(move.ToLongAlgebraic()).To(Equal("g1h1"))
+				Expect(GetBoardFromMove(board, move).ToFEN()).To(Equal("4k3/8/8/8/8/8/8/1R3RK1 b - - 0 1"))
+			})
+		})
+		When("the king passes through an attacked square", func() {
+			It("does not allow castling", func() {
+				board, _ := Chess960BoardFromFEN("3rk3/8/8/8/8/8/8/1R4KR w KQ - 0 1")
+				_, err := MoveFromAlgebraic("O-O-O", board)
+				Expect(err).To(HaveOccurred())
+				_, err = MoveFromAlgebraic("O-O", board)
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("FEN", func() {
+		It("writes castling rights in X-FEN by default and in Shredder-FEN on request", func() {
+			board, err := Chess960StartBoard(0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(board.ToFEN()).To(Equal("bbqnnrkr/pppppppp/8/8/8/8/PPPPPPPP/BBQNNRKR w KQkq - 0 1"))
+			Expect(board.ToFENWithDialect(FEN_DIALECT_SHREDDER)).To(Equal("bbqnnrkr/pppppppp/8/8/8/8/PPPPPPPP/BBQNNRKR w HFhf - 0 1"))
+		})
+		It("reads Shredder-FEN castling rights as a Chess960 board", func() {
+			board, err := BoardFromFEN("bbqnnrkr/pppppppp/8/8/8/8/PPPPPPPP/BBQNNRKR w HFhf - 0 1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(board.IsChess960).To(BeTrue())
+			Expect(board.CastleRookSquare(true, false)).To(Equal(&Square{1, 6}))
+			Expect(board.CastleRookSquare(false, true)).To(Equal(&Square{8, 8}))
+			Expect(board.ToFEN()).To(Equal("bbqnnrkr/pppppppp/8/8/8/8/PPPPPPPP/BBQNNRKR w KQkq - 0 1"))
+		})
+		It("reads X-FEN castling rights for rooks off the corners as a Chess960 board", func() {
+			board, err := BoardFromFEN("bbqnnrkr/pppppppp/8/8/8/8/PPPPPPPP/BBQNNRKR w KQkq - 0 1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(board.IsChess960).To(BeTrue())
+			Expect(board.CastleRookSquare(true, false)).To(Equal(&Square{1, 6}))
+		})
+		It("reads standard FEN castling rights as a standard board", func() {
+			board, err := BoardFromFEN("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w HAha - 0 1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(board.IsChess960).To(BeFalse())
+			Expect(board.IsInitBoard()).To(BeTrue())
+			Expect(board.ToFEN()).To(Equal("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"))
+			board, err = BoardFromFEN("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(board.IsChess960).To(BeFalse())
+		})
+		When("the castling rook is not the outermost rook", func() {
+			It("writes its file letter in X-FEN", func() {
+				fen := "r3k3/8/8/8/8/8/8/R3K1RR w Ga - 0 1"
+				board, err := BoardFromFEN(fen)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(board.CastleRookSquare(true, true)).To(Equal(&Square{1, 7}))
+				Expect(board.CanWhiteCastleQueenside).To(BeFalse())
+				Expect(board.CanBlackCastleQueenside).To(BeTrue())
+				Expect(board.ToFEN()).To(Equal("r3k3/8/8/8/8/8/8/R3K1RR w Gq - 0 1"))
+			})
+		})
+		It("rejects unknown castle rights specifiers", func() {
+			_, err := BoardFromFEN("4k3/8/8/8/8/8/8/R3K2R w Kx - 0 1")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("PGN", func() {
+		It("round trips a Chess960 game with castling", func() {
+			board, _ := Chess960StartBoard(CHESS960_STANDARD_POSITION_INDEX)
+			game := NewGameFromBoard(board)
+			for _, san := range []string{"e4", "e5", "Nf3", "Nf6", "Bc4", "Bc5", "O-O", "O-O"} {
+				Expect(game.MoveSAN(san)).To(Succeed())
+			}
+			pgn, err := PGNFromMoves(board, game.Moves(), []*PGNTag{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pgn).To(ContainSubstring("[Variant \"Chess960\"]\n"))
+			Expect(pgn).To(ContainSubstring("4. O-O O-O"))
+			Expect(game.Moves()[6].ToLongAlgebraic()).To(Equal("e1h1"))
+
+			parsedGame, err := ParsePGN(pgn)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(parsedGame.FinalBoard().ToFEN()).To(Equal(game.CurrentBoard().ToFEN()))
+			Expect(parsedGame.FinalBoard().ToFEN()).To(HavePrefix("rnbq1rk1/pppp1ppp/5n2/2b1p3/2B1P3/5N2/PPPP1PPP/RNBQ1RK1 w - -"))
+		})
+	})
+
+	DescribeTable("perft", func(fen string, expNodeCnts ...int) {
+		board, err := Chess960BoardFromFEN(fen)
+		Expect(err).ToNot(HaveOccurred())
+		for depthIdx, expNodeCnt := range expNodeCnts {
+			Expect(perft(board, depthIdx+1)).To(Equal(expNodeCnt))
+		}
+	},
+		Entry(nil, "bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w KQkq - 2 9", 21, 528, 12189),
+		Entry(nil, "2nnrbkr/p1qppppp/8/1ppb4/6PP/3PP3/PPP2P2/BQNNRBKR w KQkq - 1 9", 21, 807, 18002),
+		Entry(nil, "b1q1rrkb/pppppppp/3nn3/8/P7/1PPP4/4PPPP/BQNNRKRB w KQ - 1 9", 20, 479, 10471),
+		Entry(nil, "qbbnnrkr/2pp2pp/p7/1p2pp2/8/P3PP2/1PPP1KPP/QBBNNR1R w kq - 0 9", 22, 593, 13440),
+	)
+})
@@ -1,12 +1,28 @@
 package chess_test
 
 import (
+	"os"
 	"testing"
 
+	. "github.com/CameronHonis/chess"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
 
+// UCI_ENGINE_ENV makes the test binary act as a UCI engine, so the UCI client can be tested against a process
+const UCI_ENGINE_ENV = "CHESS_TEST_UCI_ENGINE"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(UCI_ENGINE_ENV) != "" {
+		engine := NewUCIEngine("TestEngine", "Tester", &firstMoveSearcher{options: make(map[string]string)})
+		if err := engine.Run(os.Stdin, os.Stdout); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
 func TestChess(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Chess Suite")
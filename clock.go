@@ -0,0 +1,346 @@
+package chess
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeSource lets the clock read the time from somewhere other than the system clock, e.g. a fake clock in tests
+type TimeSource interface {
+	Now() time.Time
+}
+
+type systemTimeSource struct{}
+
+func (systemTimeSource) Now() time.Time {
+	return time.Now()
+}
+
+var SystemTimeSource TimeSource = systemTimeSource{}
+
+type IncrementType string
+
+const (
+	// INCREMENT_TYPE_FISCHER adds the increment after every move
+	INCREMENT_TYPE_FISCHER IncrementType = "fischer"
+	// INCREMENT_TYPE_SIMPLE_DELAY (US delay) holds the clock for the delay before it starts counting down
+	INCREMENT_TYPE_SIMPLE_DELAY IncrementType = "simple_delay"
+	// INCREMENT_TYPE_BRONSTEIN_DELAY adds back the time spent on a move, up to the delay
+	INCREMENT_TYPE_BRONSTEIN_DELAY IncrementType = "bronstein_delay"
+)
+
+// TimeControlStage is one period of a time control. A stage with a move count hands over to the next stage
+// once a player has made that many moves in it, at which point the next stage's base time is added to the
+// player's clock. The last stage repeats if it has a move count, otherwise it lasts for the rest of the game.
+type TimeControlStage struct {
+	Moves         uint
+	Base          time.Duration
+	Increment     time.Duration
+	IncrementType IncrementType
+}
+
+func (stage *TimeControlStage) String() string {
+	var builder strings.Builder
+	builder.WriteString(formatTimeControlMinutes(stage.Base))
+	if stage.Moves > 0 {
+		builder.WriteString(fmt.Sprintf("/%d", stage.Moves))
+	}
+	if stage.Increment > 0 {
+		builder.WriteString("+" + strconv.FormatFloat(stage.Increment.Seconds(), 'f', -1, 64))
+		switch stage.IncrementType {
+		case INCREMENT_TYPE_SIMPLE_DELAY:
+			builder.WriteString("d")
+		case INCREMENT_TYPE_BRONSTEIN_DELAY:
+			builder.WriteString("b")
+		}
+	}
+	return builder.String()
+}
+
+type TimeControl struct {
+	Stages []*TimeControlStage
+}
+
+// ParseTimeControl reads a comma separated list of stages. Each stage is written as minutes, optionally
+// followed by "/moves" and by "+seconds" of increment, e.g. "5+3" or "90/40+30,30+30". An increment
+// suffixed with "d" is a simple delay and one suffixed with "b" is a Bronstein delay, e.g. "5+2d".
+func ParseTimeControl(timeControl string) (*TimeControl, error) {
+	stageStrs := strings.Split(strings.TrimSpace(timeControl), ",")
+	stages := make([]*TimeControlStage, 0, len(stageStrs))
+	for idx, stageStr := range stageStrs {
+		stage, stageErr := parseTimeControlStage(strings.TrimSpace(stageStr))
+		if stageErr != nil {
+			return nil, fmt.Errorf("invalid time control %s: %w", timeControl, stageErr)
+		}
+		if stage.Moves == 0 && idx < len(stageStrs)-1 {
+			return nil, fmt.Errorf("invalid time control %s: stage %s lasts the rest of the game but is followed by more stages", timeControl, stageStr)
+		}
+		stages = append(stages, stage)
+	}
+	return &TimeControl{stages}, nil
+}
+
+func (timeControl *TimeControl) String() string {
+	stageStrs := make([]string, len(timeControl.Stages))
+	for idx, stage := range timeControl.Stages {
+		stageStrs[idx] = stage.String()
+	}
+	return strings.Join(stageStrs, ",")
+}
+
+func parseTimeControlStage(stageStr string) (*TimeControlStage, error) {
+	stage := &TimeControlStage{IncrementType: INCREMENT_TYPE_FISCHER}
+	baseStr, incrementStr, hasIncrement := strings.Cut(stageStr, "+")
+	minutesStr, movesStr, hasMoves := strings.Cut(baseStr, "/")
+
+	minutes, minutesErr := strconv.ParseFloat(minutesStr, 64)
+	if minutesErr != nil || minutes < 0 {
+		return nil, fmt.Errorf("invalid base time %s in stage %s", minutesStr, stageStr)
+	}
+	stage.Base = time.Duration(minutes * float64(time.Minute))
+	if hasMoves {
+		moves, movesErr := strconv.ParseUint(movesStr, 10, 32)
+		if movesErr != nil || moves == 0 {
+			return nil, fmt.Errorf("invalid move count %s in stage %s", movesStr, stageStr)
+		}
+		stage.Moves = uint(moves)
+	}
+	if hasIncrement {
+		if strings.HasSuffix(incrementStr, "d") {
+			stage.IncrementType = INCREMENT_TYPE_SIMPLE_DELAY
+			incrementStr = strings.TrimSuffix(incrementStr, "d")
+		} else if strings.HasSuffix(incrementStr, "b") {
+			stage.IncrementType = INCREMENT_TYPE_BRONSTEIN_DELAY
+			incrementStr = strings.TrimSuffix(incrementStr, "b")
+		}
+		seconds, secondsErr := strconv.ParseFloat(incrementStr, 64)
+		if secondsErr != nil || seconds < 0 {
+			return nil, fmt.Errorf("invalid increment %s in stage %s", incrementStr, stageStr)
+		}
+		stage.Increment = time.Duration(seconds * float64(time.Second))
+	}
+	if stage.Base == 0 && stage.Increment == 0 {
+		return nil, fmt.Errorf("stage %s has no time", stageStr)
+	}
+	return stage, nil
+}
+
+func formatTimeControlMinutes(duration time.Duration) string {
+	return strconv.FormatFloat(duration.Minutes(), 'f', -1, 64)
+}
+
+// Clock is a two sided chess clock. Only the side to move has its time running; pressing the clock ends that
+// side's turn, applies the increment or delay of its current stage and starts the opponent's time.
+type Clock struct {
+	timeControl *TimeControl
+	timeSource  TimeSource
+
+	whiteRemaining  time.Duration
+	blackRemaining  time.Duration
+	whiteStageIdx   int
+	blackStageIdx   int
+	whiteStageMoves uint
+	blackStageMoves uint
+	isWhiteTurn     bool
+	isRunning       bool
+	hasStarted      bool
+	turnStartedAt   time.Time
+	hasFlagFallen   bool
+	isWhiteFlagged  bool
+}
+
+// NewClock sets both sides to the base time of the time control's first stage, reading the time from the time
+// source or from the system clock if it is nil. The time control needs at least one stage.
+func NewClock(timeControl *TimeControl, timeSource TimeSource) (*Clock, error) {
+	if timeControl == nil || len(timeControl.Stages) == 0 {
+		return nil, fmt.Errorf("cannot make clock, time control has no stages")
+	}
+	for idx, stage := range timeControl.Stages {
+		if stage == nil {
+			return nil, fmt.Errorf("cannot make clock, stage %d of the time control is nil", idx+1)
+		}
+	}
+	if timeSource == nil {
+		timeSource = SystemTimeSource
+	}
+	firstStage := timeControl.Stages[0]
+	return &Clock{
+		timeControl:    timeControl,
+		timeSource:     timeSource,
+		whiteRemaining: firstStage.Base,
+		blackRemaining: firstStage.Base,
+		isWhiteTurn:    true,
+	}, nil
+}
+
+func (clock *Clock) TimeControl() *TimeControl {
+	return clock.timeControl
+}
+
+func (clock *Clock) IsRunning() bool {
+	return clock.isRunning
+}
+
+func (clock *Clock) HasStarted() bool {
+	return clock.hasStarted
+}
+
+func (clock *Clock) IsWhiteTurn() bool {
+	return clock.isWhiteTurn
+}
+
+// Start runs the time of the given side. A clock can only be started once; use Press to switch sides.
+func (clock *Clock) Start(isWhiteTurn bool) error {
+	if clock.hasStarted {
+		return fmt.Errorf("cannot start clock, clock has already been started")
+	}
+	clock.hasStarted = true
+	clock.isRunning = true
+	clock.isWhiteTurn = isWhiteTurn
+	clock.turnStartedAt = clock.timeSource.Now()
+	return nil
+}
+
+// Stop freezes both sides' time, e.g. once the game has ended
+func (clock *Clock) Stop() {
+	if !clock.isRunning {
+		return
+	}
+	clock.setRemaining(clock.isWhiteTurn, clock.Remaining(clock.isWhiteTurn))
+	clock.isRunning = false
+}
+
+// Press ends the turn of the side to move and starts the opponent's time. It returns an error without
+// switching sides if the side to move ran out of time before pressing.
+func (clock *Clock) Press() error {
+	if !clock.isRunning {
+		return fmt.Errorf("cannot press clock, clock is not running")
+	}
+	now := clock.timeSource.Now()
+	elapsed := now.Sub(clock.turnStartedAt)
+	stage := clock.currentStage(clock.isWhiteTurn)
+	remaining := clock.getRemaining(clock.isWhiteTurn) - clock.chargedTime(stage, elapsed)
+	if remaining <= 0 {
+		clock.flag(clock.isWhiteTurn)
+		return fmt.Errorf("cannot press clock, %s has run out of time", sideName(clock.isWhiteTurn))
+	}
+
+	switch stage.IncrementType {
+	case INCREMENT_TYPE_FISCHER:
+		remaining += stage.Increment
+	case INCREMENT_TYPE_BRONSTEIN_DELAY:
+		if elapsed < stage.Increment {
+			remaining += elapsed
+		} else {
+			remaining += stage.Increment
+		}
+	}
+	remaining += clock.countStageMove(clock.isWhiteTurn)
+	clock.setRemaining(clock.isWhiteTurn, remaining)
+
+	clock.isWhiteTurn = !clock.isWhiteTurn
+	clock.turnStartedAt = now
+	return nil
+}
+
+// SetTurn hands the running time to the given side without ending a turn, so no increment or delay is
+// applied, e.g. when a move is taken back. Time already spent by the side to move stays spent.
+func (clock *Clock) SetTurn(isWhiteTurn bool) {
+	if clock.isWhiteTurn == isWhiteTurn {
+		return
+	}
+	if clock.isRunning {
+		clock.setRemaining(clock.isWhiteTurn, clock.Remaining(clock.isWhiteTurn))
+		clock.turnStartedAt = clock.timeSource.Now()
+	}
+	clock.isWhiteTurn = isWhiteTurn
+}
+
+// Remaining returns the time left for the given side, including the time used so far on a running turn
+func (clock *Clock) Remaining(isWhite bool) time.Duration {
+	if clock.hasFlagFallen && clock.isWhiteFlagged == isWhite {
+		return 0
+	}
+	remaining := clock.getRemaining(isWhite)
+	if clock.isRunning && clock.isWhiteTurn == isWhite {
+		elapsed := clock.timeSource.Now().Sub(clock.turnStartedAt)
+		remaining -= clock.chargedTime(clock.currentStage(isWhite), elapsed)
+	}
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// IsFlagged reports whether the given side has run out of time
+func (clock *Clock) IsFlagged(isWhite bool) bool {
+	return clock.Remaining(isWhite) <= 0
+}
+
+// chargedTime is the part of the time spent on a move that comes off the clock
+func (clock *Clock) chargedTime(stage *TimeControlStage, elapsed time.Duration) time.Duration {
+	if stage.IncrementType != INCREMENT_TYPE_SIMPLE_DELAY {
+		return elapsed
+	}
+	if elapsed <= stage.Increment {
+		return 0
+	}
+	return elapsed - stage.Increment
+}
+
+// countStageMove counts a move towards the side's current stage, returning the time added if that move
+// completes the stage
+func (clock *Clock) countStageMove(isWhite bool) time.Duration {
+	stageIdx, stageMoves := &clock.blackStageIdx, &clock.blackStageMoves
+	if isWhite {
+		stageIdx, stageMoves = &clock.whiteStageIdx, &clock.whiteStageMoves
+	}
+	stage := clock.timeControl.Stages[*stageIdx]
+	*stageMoves++
+	if stage.Moves == 0 || *stageMoves < stage.Moves {
+		return 0
+	}
+	*stageMoves = 0
+	if *stageIdx < len(clock.timeControl.Stages)-1 {
+		*stageIdx++
+	}
+	return clock.timeControl.Stages[*stageIdx].Base
+}
+
+func (clock *Clock) currentStage(isWhite bool) *TimeControlStage {
+	if isWhite {
+		return clock.timeControl.Stages[clock.whiteStageIdx]
+	}
+	return clock.timeControl.Stages[clock.blackStageIdx]
+}
+
+func (clock *Clock) flag(isWhite bool) {
+	clock.setRemaining(isWhite, 0)
+	clock.hasFlagFallen = true
+	clock.isWhiteFlagged = isWhite
+	clock.isRunning = false
+}
+
+func (clock *Clock) getRemaining(isWhite bool) time.Duration {
+	if isWhite {
+		return clock.whiteRemaining
+	}
+	return clock.blackRemaining
+}
+
+func (clock *Clock) setRemaining(isWhite bool, remaining time.Duration) {
+	if isWhite {
+		clock.whiteRemaining = remaining
+	} else {
+		clock.blackRemaining = remaining
+	}
+}
+
+func sideName(isWhite bool) string {
+	if isWhite {
+		return "white"
+	}
+	return "black"
+}
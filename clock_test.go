@@ -0,0 +1,217 @@
+package chess_test
+
+import (
+	"time"
+
+	. "github.com/CameronHonis/chess"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type fakeTimeSource struct {
+	now time.Time
+}
+
+func (timeSource *fakeTimeSource) Now() time.Time {
+	return timeSource.now
+}
+
+func (timeSource *fakeTimeSource) Advance(duration time.Duration) {
+	timeSource.now = timeSource.now.Add(duration)
+}
+
+func newTestClock(timeControlStr string) (*Clock, *fakeTimeSource) {
+	timeControl, err := ParseTimeControl(timeControlStr)
+	Expect(err).ToNot(HaveOccurred())
+	timeSource := &fakeTimeSource{time.Unix(0, 0)}
+	clock, clockErr := NewClock(timeControl, timeSource)
+	Expect(clockErr).ToNot(HaveOccurred())
+	return clock, timeSource
+}
+
+var _ = Describe("TimeControl", func() {
+	Describe("#ParseTimeControl", func() {
+		It("parses a single stage with an increment", func() {
+			timeControl, err := ParseTimeControl("5+3")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(timeControl.Stages).To(Equal([]*TimeControlStage{
+				{Base: 5 * time.Minute, Increment: 3 * time.Second, IncrementType: INCREMENT_TYPE_FISCHER},
+			}))
+		})
+		It("parses stages with move counts", func() {
+			timeControl, err := ParseTimeControl("90/40+30,30+30")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(timeControl.Stages).To(Equal([]*TimeControlStage{
+				{Moves: 40, Base: 90 * time.Minute, Increment: 30 * time.Second, IncrementType: INCREMENT_TYPE_FISCHER},
+				{Base: 30 * time.Minute, Increment: 30 * time.Second, IncrementType: INCREMENT_TYPE_FISCHER},
+			}))
+		})
+		It("parses delays", func() {
+			timeControl, err := ParseTimeControl("5+2d")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(timeControl.Stages[0].IncrementType).To(Equal(INCREMENT_TYPE_SIMPLE_DELAY))
+			timeControl, err = ParseTimeControl("0.5+2b")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(timeControl.Stages[0].Base).To(Equal(30 * time.Second))
+			Expect(timeControl.Stages[0].IncrementType).To(Equal(INCREMENT_TYPE_BRONSTEIN_DELAY))
+		})
+		It("rejects a stage for the rest of the game that isn't the last stage", func() {
+			_, err := ParseTimeControl("90+30,30+30")
+			Expect(err).To(HaveOccurred())
+		})
+		DescribeTable("round trips through String", func(timeControlStr string) {
+			timeControl, err := ParseTimeControl(timeControlStr)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(timeControl.String()).To(Equal(timeControlStr))
+		},
+			Entry("blitz", "5+3"),
+			Entry("no increment", "1"),
+			Entry("classical", "90/40+30,30+30"),
+			Entry("simple delay", "5+5d"),
+			Entry("bronstein delay", "0.5+2b"),
+		)
+	})
+})
+
+var _ = Describe("Clock", func() {
+	Describe("#NewClock", func() {
+		It("starts both sides on the first stage's base time", func() {
+			clock, _ := newTestClock("5+3")
+			Expect(clock.Remaining(true)).To(Equal(5 * time.Minute))
+			Expect(clock.Remaining(false)).To(Equal(5 * time.Minute))
+		})
+		It("rejects a time control without stages", func() {
+			_, err := NewClock(&TimeControl{}, nil)
+			Expect(err).To(HaveOccurred())
+			_, err = NewClock(nil, nil)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Describe("::Press", func() {
+		When("the time control has a Fischer increment", func() {
+			It("adds the increment after every move", func() {
+				clock, timeSource := newTestClock("5+3")
+				Expect(clock.Start(true)).To(Succeed())
+				timeSource.Advance(10 * time.Second)
+				Expect(clock.Remaining(true)).To(Equal(4*time.Minute + 50*time.Second))
+				Expect(clock.Press()).To(Succeed())
+				Expect(clock.Remaining(true)).To(Equal(4*time.Minute + 53*time.Second))
+				timeSource.Advance(time.Minute)
+				Expect(clock.Remaining(false)).To(Equal(4 * time.Minute))
+				Expect(clock.Remaining(true)).To(Equal(4*time.Minute + 53*time.Second))
+			})
+		})
+		When("the time control has a simple delay", func() {
+			It("only charges the time spent beyond the delay", func() {
+				clock, timeSource := newTestClock("5+5d")
+				Expect(clock.Start(true)).To(Succeed())
+				timeSource.Advance(4 * time.Second)
+				Expect(clock.Remaining(true)).To(Equal(5 * time.Minute))
+				timeSource.Advance(4 * time.Second)
+				Expect(clock.Press()).To(Succeed())
+				Expect(clock.Remaining(true)).To(Equal(4*time.Minute + 57*time.Second))
+			})
+		})
+		When("the time control has a Bronstein delay", func() {
+			It("adds back the time spent, up to the delay", func() {
+				clock, timeSource := newTestClock("5+5b")
+				Expect(clock.Start(true)).To(Succeed())
+				timeSource.Advance(3 * time.Second)
+				Expect(clock.Press()).To(Succeed())
+				Expect(clock.Remaining(true)).To(Equal(5 * time.Minute))
+				timeSource.Advance(8 * time.Second)
+				Expect(clock.Press()).To(Succeed())
+				Expect(clock.Remaining(false)).To(Equal(4*time.Minute + 57*time.Second))
+			})
+		})
+		When("a stage's moves have been played", func() {
+			It("adds the next stage's time", func() {
+				clock, timeSource := newTestClock("1/2,1")
+				Expect(clock.Start(true)).To(Succeed())
+				for i := 0; i < 4; i++ {
+					timeSource.Advance(time.Second)
+					Expect(clock.Press()).To(Succeed())
+				}
+				Expect(clock.Remaining(true)).To(Equal(time.Minute + 58*time.Second))
+				Expect(clock.Remaining(false)).To(Equal(time.Minute + 58*time.Second))
+			})
+		})
+		When("the side to move has run out of time", func() {
+			It("flags the side and stops the clock", func() {
+				clock, timeSource := newTestClock("1")
+				Expect(clock.Start(true)).To(Succeed())
+				timeSource.Advance(time.Minute)
+				Expect(clock.IsFlagged(true)).To(BeTrue())
+				Expect(clock.Press()).ToNot(Succeed())
+				Expect(clock.IsRunning()).To(BeFalse())
+				Expect(clock.IsFlagged(false)).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("::Stop", func() {
+		It("freezes the remaining time", func() {
+			clock, timeSource := newTestClock("1")
+			Expect(clock.Start(false)).To(Succeed())
+			timeSource.Advance(10 * time.Second)
+			clock.Stop()
+			timeSource.Advance(10 * time.Second)
+			Expect(clock.Remaining(false)).To(Equal(50 * time.Second))
+			Expect(clock.Press()).ToNot(Succeed())
+		})
+	})
+
+	Describe("integration with Game", func() {
+		var game *Game
+		var clock *Clock
+		var timeSource *fakeTimeSource
+		BeforeEach(func() {
+			clock, timeSource = newTestClock("1+1")
+			game = NewGame()
+			game.SetClock(clock)
+		})
+		It("starts the clock on the first move and presses it on every move", func() {
+			Expect(game.MoveSAN("e4")).To(Succeed())
+			Expect(clock.IsWhiteTurn()).To(BeFalse())
+			timeSource.Advance(5 * time.Second)
+			Expect(game.MoveSAN("e5")).To(Succeed())
+			Expect(clock.Remaining(false)).To(Equal(56 * time.Second))
+		})
+		It("charges neither time nor increment for the first move", func() {
+			timeSource.Advance(5 * time.Second)
+			Expect(game.MoveSAN("e4")).To(Succeed())
+			Expect(clock.Remaining(true)).To(Equal(time.Minute))
+			Expect(clock.IsRunning()).To(BeTrue())
+		})
+		It("runs the clock for the side to move after an undo or redo", func() {
+			Expect(game.MoveSAN("e4")).To(Succeed())
+			timeSource.Advance(5 * time.Second)
+			Expect(game.MoveSAN("e5")).To(Succeed())
+			Expect(game.Undo()).To(Succeed())
+			Expect(clock.IsWhiteTurn()).To(BeFalse())
+			Expect(clock.Remaining(false)).To(Equal(56 * time.Second))
+			timeSource.Advance(3 * time.Second)
+			Expect(game.Redo()).To(Succeed())
+			Expect(clock.IsWhiteTurn()).To(BeTrue())
+			Expect(clock.Remaining(false)).To(Equal(53 * time.Second))
+		})
+		It("ends the game by timeout when the side to move flags", func() {
+			Expect(game.MoveSAN("e4")).To(Succeed())
+			timeSource.Advance(2 * time.Minute)
+			Expect(game.MoveSAN("e5")).ToNot(Succeed())
+			Expect(game.Outcome()).To(Equal(NewGameOutcome(GAME_RESULT_WHITE_WINS, GAME_RESULT_REASON_TIMEOUT)))
+			Expect(game.Moves()).To(HaveLen(1))
+		})
+		It("notices a flag fall without a move being made", func() {
+			Expect(game.MoveSAN("e4")).To(Succeed())
+			timeSource.Advance(2 * time.Minute)
+			Expect(game.IsOver()).To(BeTrue())
+			Expect(game.Outcome().Reason).To(Equal(GAME_RESULT_REASON_TIMEOUT))
+		})
+		It("stops the clock when the game ends", func() {
+			Expect(game.MoveSAN("e4")).To(Succeed())
+			Expect(game.Resign(false)).To(Succeed())
+			Expect(clock.IsRunning()).To(BeFalse())
+		})
+	})
+})
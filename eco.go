@@ -0,0 +1,149 @@
+package chess
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ecoTSV lists the openings as tab separated code, name, variation and the moves defining the opening from the
+// initial board, with a header line. Every code from A00 to E99 has its main line and the common named variations,
+// not every sub-variation of the printed encyclopaedia.
+//
+//go:embed eco.tsv
+var ecoTSV string
+
+// ECOOpening is an opening of the Encyclopaedia of Chess Openings, defined by the position its moves lead to
+type ECOOpening struct {
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+	Variation string `json:"variation,omitempty"`
+	// Moves is the movetext of the moves defining the opening, e.g. "1. e4 e5 2. Nf3 Nc6 3. d4"
+	Moves string `json:"moves"`
+
+	ply int
+}
+
+// String gives the label of the opening, e.g. "C45 Scotch Game: Classical Variation"
+func (opening *ECOOpening) String() string {
+	if opening.Variation == "" {
+		return fmt.Sprintf("%s %s", opening.Code, opening.Name)
+	}
+	return fmt.Sprintf("%s %s: %s", opening.Code, opening.Name, opening.Variation)
+}
+
+// copy gives a copy of the opening, so callers can't change the openings of the database
+func (opening *ECOOpening) copy() *ECOOpening {
+	openingCopy := *opening
+	return &openingCopy
+}
+
+type ecoDatabase struct {
+	openings []*ECOOpening
+	// openingsByKey holds the first opening in the database to reach each position
+	openingsByKey map[uint64]*ECOOpening
+}
+
+var ecoDatabaseOnce sync.Once
+var ecoDatabaseInstance *ecoDatabase
+
+// getECODatabase replays the openings' moves on first use, to key them by the position they reach
+func getECODatabase() *ecoDatabase {
+	ecoDatabaseOnce.Do(func() {
+		database, err := parseECODatabase(ecoTSV)
+		if err != nil {
+			panic(err)
+		}
+		ecoDatabaseInstance = database
+	})
+	return ecoDatabaseInstance
+}
+
+func parseECODatabase(tsv string) (*ecoDatabase, error) {
+	database := &ecoDatabase{
+		openings:      make([]*ECOOpening, 0),
+		openingsByKey: make(map[uint64]*ECOOpening),
+	}
+	lines := strings.Split(strings.TrimSpace(tsv), "\n")
+	for lineIdx, line := range lines[1:] {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("eco line %d has %d fields, expected 4", lineIdx+2, len(fields))
+		}
+		opening := &ECOOpening{
+			Code:      fields[0],
+			Name:      fields[1],
+			Variation: fields[2],
+			Moves:     fields[3],
+		}
+		board := GetInitBoard()
+		for _, token := range strings.Fields(opening.Moves) {
+			if isPGNMoveNumber(strings.TrimRight(token, ".")) {
+				continue
+			}
+			move, err := MoveFromAlgebraic(sanFromPGNToken(token), board)
+			if err != nil {
+				return nil, fmt.Errorf("eco line %d, %s: %w", lineIdx+2, opening, err)
+			}
+			board = GetBoardFromMove(board, move)
+			opening.ply++
+		}
+		database.openings = append(database.openings, opening)
+		key := board.ZobristKey()
+		if _, ok := database.openingsByKey[key]; !ok {
+			database.openingsByKey[key] = opening
+		}
+	}
+	return database, nil
+}
+
+// ECOOpenings returns copies of the openings of the embedded ECO database, ordered by code
+func ECOOpenings() []*ECOOpening {
+	openings := getECODatabase().openings
+	openingCopies := make([]*ECOOpening, len(openings))
+	for idx, opening := range openings {
+		openingCopies[idx] = opening.copy()
+	}
+	return openingCopies
+}
+
+// ClassifyOpening returns a copy of the opening whose moves lead to the board's position, or nil if none does. A
+// board doesn't keep the positions before it, so a game that has left its opening classifies as nil; to find the
+// opening a game went through, see ClassifyOpeningFromBoards.
+func ClassifyOpening(board *Board) *ECOOpening {
+	opening, ok := getECODatabase().openingsByKey[board.ZobristKey()]
+	if !ok {
+		return nil
+	}
+	return opening.copy()
+}
+
+// ClassifyOpeningFromBoards returns the deepest opening the positions went through, the one defined by the most
+// moves, or nil if they went through none. Openings are recognized by position, so move orders that transpose
+// into an opening classify as the opening.
+func ClassifyOpeningFromBoards(boards []*Board) *ECOOpening {
+	var deepestOpening *ECOOpening
+	for _, board := range boards {
+		opening := ClassifyOpening(board)
+		if opening != nil && (deepestOpening == nil || opening.ply >= deepestOpening.ply) {
+			deepestOpening = opening
+		}
+	}
+	return deepestOpening
+}
+
+// ClassifyOpeningFromMoves plays the moves from the initial board and classifies the positions they go through,
+// see ClassifyOpeningFromBoards
+func ClassifyOpeningFromMoves(moves []*Move) (*ECOOpening, error) {
+	board := GetInitBoard()
+	boards := []*Board{board}
+	for _, move := range moves {
+		if !IsLegalMove(board, move) {
+			return nil, fmt.Errorf("move %s is not legal on %s", move.ToLongAlgebraic(), board)
+		}
+		board = GetBoardFromMove(board, move)
+		boards = append(boards, board)
+	}
+	return ClassifyOpeningFromBoards(boards), nil
+}
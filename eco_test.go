@@ -0,0 +1,78 @@
+package chess_test
+
+import (
+	. "github.com/CameronHonis/chess"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ECO", func() {
+	Describe("#ECOOpenings", func() {
+		It("holds openings that each reach a position of their own", func() {
+			openings := ECOOpenings()
+			Expect(len(openings)).To(BeNumerically(">", 500))
+			for _, opening := range openings {
+				game, err := ParsePGN(opening.Moves + " *")
+				Expect(err).ToNot(HaveOccurred(), opening.String())
+				Expect(ClassifyOpening(game.FinalBoard())).To(Equal(opening), opening.String())
+			}
+		})
+		It("covers every code from A00 to E99", func() {
+			codes := make(map[string]bool)
+			for _, opening := range ECOOpenings() {
+				codes[opening.Code] = true
+			}
+			Expect(codes).To(HaveLen(500))
+		})
+		It("returns copies the caller can change without changing the database", func() {
+			ECOOpenings()[0].Name = "Changed"
+			Expect(ECOOpenings()[0].Name).ToNot(Equal("Changed"))
+			game, _ := ParsePGN("1. e4 e5 2. Nf3 Nc6 3. d4 exd4 4. Nxd4 *")
+			ClassifyOpening(game.FinalBoard()).Name = "Changed"
+			Expect(ClassifyOpening(game.FinalBoard()).Name).To(Equal("Scotch Game"))
+		})
+	})
+
+	Describe("#ClassifyOpening", func() {
+		It("classifies the board's position", func() {
+			game, _ := ParsePGN("1. e4 e5 2. Nf3 Nc6 3. d4 exd4 4. Nxd4 *")
+			Expect(ClassifyOpening(game.FinalBoard()).String()).To(Equal("C45 Scotch Game"))
+		})
+		It("returns nil for a position out of the database", func() {
+			game, _ := ParsePGN("1. e4 e5 2. Nf3 Nc6 3. d4 exd4 4. Nxd4 Qh4 *")
+			Expect(ClassifyOpening(game.FinalBoard())).To(BeNil())
+		})
+	})
+
+	Describe("#ClassifyOpeningFromBoards", func() {
+		It("returns the deepest opening the game went through", func() {
+			game, _ := ParsePGN("1. e4 c5 2. Nf3 d6 3. d4 cxd4 4. Nxd4 Nf6 5. Nc3 a6 6. Be3 e5 7. Nb3 Be6 *")
+			opening := ClassifyOpeningFromBoards(game.Boards)
+			Expect(opening.String()).To(Equal("B90 Sicilian Defense: Najdorf Variation, English Attack"))
+		})
+		It("recognizes a transposition into an opening", func() {
+			game, _ := ParsePGN("1. Nf3 Nc6 2. e4 e5 3. d4 exd4 4. Nxd4 Bc5 5. Nb3 *")
+			opening := ClassifyOpeningFromBoards(game.Boards)
+			Expect(opening.String()).To(Equal("C45 Scotch Game: Classical Variation"))
+			Expect(opening.Moves).To(Equal("1. e4 e5 2. Nf3 Nc6 3. d4 exd4 4. Nxd4 Bc5"))
+		})
+		It("returns nil when the game went through no opening of the database", func() {
+			board, _ := BoardFromFEN("8/8/8/8/7K/8/6R1/k7 w - - 0 1")
+			Expect(ClassifyOpeningFromBoards([]*Board{board})).To(BeNil())
+		})
+	})
+
+	Describe("#ClassifyOpeningFromMoves", func() {
+		It("plays the moves from the initial board", func() {
+			game, _ := ParsePGN("1. d4 Nf6 2. c4 e6 3. Nc3 Bb4 4. e3 O-O *")
+			opening, err := ClassifyOpeningFromMoves(game.Moves)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(opening.String()).To(Equal("E46 Nimzo-Indian Defense: Normal Variation, 4...O-O"))
+		})
+		It("rejects an illegal move", func() {
+			move := &Move{Piece: WHITE_PAWN, StartSquare: &Square{2, 5}, EndSquare: &Square{5, 5}}
+			_, err := ClassifyOpeningFromMoves([]*Move{move})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
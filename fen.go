@@ -0,0 +1,137 @@
+package chess
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FENDialect picks how castling rights are written in a FEN
+type FENDialect string
+
+const (
+	// FEN_DIALECT_X_FEN writes KQkq for the outermost rook on each side of the king and the rook's file letter
+	// otherwise. For standard chess positions this is plain FEN.
+	FEN_DIALECT_X_FEN FENDialect = "x_fen"
+	// FEN_DIALECT_SHREDDER always writes the castling rook's file letter, e.g. HAha
+	FEN_DIALECT_SHREDDER FENDialect = "shredder"
+)
+
+// readFENCastleRights reads the castle rights segment of a FEN, accepting standard FEN, X-FEN and Shredder-FEN
+// alike. The pieces must already be on the board. Any castling rook that isn't where a standard chess rook
+// starts makes the board a Chess960 board, while file letters naming the corner rooks of a king on the e-file,
+// e.g. HAha for the initial position, read as standard castling rights. Rights that don't fit the pieces are
+// left for validation to reject or repair.
+func readFENCastleRights(boardBuilder *BoardBuilder, fenSeg string) error {
+	board := boardBuilder.board
+	isChess960 := false
+	for _, castleRightsRune := range fenSeg {
+		isWhite := castleRightsRune >= 'A' && castleRightsRune <= 'Z'
+		kingSquare := board.GetKingSquare(isWhite)
+		hasBackRankKing := kingSquare != nil && kingSquare.Rank == backRank(isWhite)
+
+		var isKingside bool
+		var rookFile uint8
+		switch castleRightsRune {
+		case 'K', 'k', 'Q', 'q':
+			isKingside = castleRightsRune == 'K' || castleRightsRune == 'k'
+			if !hasBackRankKing {
+				break
+			}
+			file, ok := outermostRookFile(board, isWhite, isKingside)
+			if !ok {
+				break
+			}
+			isStandardRook := kingSquare.File == 5 && ((isKingside && file == 8) || (!isKingside && file == 1))
+			if !isStandardRook {
+				rookFile = file
+				isChess960 = true
+			}
+		default:
+			lowerRune := castleRightsRune
+			if isWhite {
+				lowerRune = castleRightsRune - 'A' + 'a'
+			}
+			if lowerRune < 'a' || lowerRune > 'h' {
+				return fmt.Errorf("invalid FEN: unknown castle rights specifier, got %c", castleRightsRune)
+			}
+			rookFile = uint8(lowerRune-'a') + 1
+			kingFile := uint8(5)
+			if kingSquare != nil {
+				kingFile = kingSquare.File
+			}
+			isKingside = rookFile > kingFile
+			isStandardRook := hasBackRankKing && kingFile == 5 && ((isKingside && rookFile == 8) || (!isKingside && rookFile == 1))
+			if isStandardRook {
+				rookFile = 0
+			} else {
+				isChess960 = true
+			}
+		}
+
+		if isWhite && isKingside {
+			boardBuilder.WithCanWhiteCastleKingside(true)
+		} else if isWhite {
+			boardBuilder.WithCanWhiteCastleQueenside(true)
+		} else if isKingside {
+			boardBuilder.WithCanBlackCastleKingside(true)
+		} else {
+			boardBuilder.WithCanBlackCastleQueenside(true)
+		}
+		boardBuilder.WithCastleRookFile(isWhite, isKingside, rookFile)
+	}
+	if isChess960 {
+		boardBuilder.WithIsChess960(true)
+	}
+	return nil
+}
+
+// fenCastleRights writes the castle rights segment of a FEN in the given dialect
+func (board *Board) fenCastleRights(dialect FENDialect) string {
+	var castleRightsBuilder strings.Builder
+	for _, isWhite := range []bool{true, false} {
+		for _, isKingside := range []bool{true, false} {
+			if !board.hasCastleRights(isWhite, isKingside) {
+				continue
+			}
+			castleRightsBuilder.WriteRune(board.fenCastleRightsRune(dialect, isWhite, isKingside))
+		}
+	}
+	if castleRightsBuilder.Len() == 0 {
+		return "-"
+	}
+	return castleRightsBuilder.String()
+}
+
+func (board *Board) fenCastleRightsRune(dialect FENDialect, isWhite bool, isKingside bool) rune {
+	rookFile := board.CastleRookSquare(isWhite, isKingside).File
+	castleRightsRune := 'a' + rune(rookFile-1)
+	if dialect != FEN_DIALECT_SHREDDER {
+		kingSquare := board.GetKingSquare(isWhite)
+		outermostFile, ok := uint8(0), false
+		if kingSquare != nil && kingSquare.Rank == backRank(isWhite) {
+			outermostFile, ok = outermostRookFile(board, isWhite, isKingside)
+		}
+		if !ok || outermostFile == rookFile {
+			if isKingside {
+				castleRightsRune = 'k'
+			} else {
+				castleRightsRune = 'q'
+			}
+		}
+	}
+	if isWhite {
+		castleRightsRune = castleRightsRune - 'a' + 'A'
+	}
+	return castleRightsRune
+}
+
+func (board *Board) hasCastleRights(isWhite bool, isKingside bool) bool {
+	if isWhite && isKingside {
+		return board.CanWhiteCastleKingside
+	} else if isWhite {
+		return board.CanWhiteCastleQueenside
+	} else if isKingside {
+		return board.CanBlackCastleKingside
+	}
+	return board.CanBlackCastleQueenside
+}
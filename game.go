@@ -0,0 +1,385 @@
+package chess
+
+import "fmt"
+
+// Game owns the history of a single game. Boards are immutable snapshots, so undoing a move just steps back to
+// the previous board, repetition counts included. Undone moves are kept for Redo until a different move is
+// played.
+type Game struct {
+	boards []*Board
+	moves  []*Move
+	ply    int
+	// outcome is only set when the game ends off the board, otherwise the outcome follows the current board
+	outcome              *GameOutcome
+	hasDrawOffer         bool
+	isDrawOfferedByWhite bool
+	clock                *Clock
+}
+
+func NewGame() *Game {
+	return NewGameFromBoard(GetInitBoard())
+}
+
+// NewGameWithRules starts a game from the initial position that is drawn by the given rules
+func NewGameWithRules(rules *Rules) *Game {
+	return NewGameFromBoard(NewBoardBuilder().FromBoard(GetInitBoard()).WithRules(rules).Build())
+}
+
+// NewGameFromBoard starts a game from the board, playing by the board's rules
+func NewGameFromBoard(board *Board) *Game {
+	return &Game{
+		boards: []*Board{board},
+		moves:  make([]*Move, 0),
+		ply:    0,
+	}
+}
+
+func (game *Game) CurrentBoard() *Board {
+	return game.boards[game.ply]
+}
+
+func (game *Game) Rules() *Rules {
+	return game.boards[0].Rules()
+}
+
+// SetClock times the game with the clock. Unless it was already started, the clock starts running for the
+// opponent once the first move is played, so the first move neither costs time nor earns an increment,
+// and a flag fall ends the game as soon as it's noticed.
+func (game *Game) SetClock(clock *Clock) {
+	game.clock = clock
+}
+
+func (game *Game) Clock() *Clock {
+	return game.clock
+}
+
+func (game *Game) Outcome() *GameOutcome {
+	game.adjudicateFlag()
+	if game.outcome != nil {
+		return game.outcome
+	}
+	return GameOutcomeFromBoardResult(game.CurrentBoard().Result)
+}
+
+func (game *Game) IsOver() bool {
+	return game.Outcome().IsOver()
+}
+
+// Positions returns every board from the start of the game up to and including the current board
+func (game *Game) Positions() []*Board {
+	positions := make([]*Board, game.ply+1)
+	copy(positions, game.boards[:game.ply+1])
+	return positions
+}
+
+func (game *Game) Moves() []*Move {
+	moves := make([]*Move, game.ply)
+	copy(moves, game.moves[:game.ply])
+	return moves
+}
+
+// Opening returns the deepest ECO opening the game has gone through, see ClassifyOpeningFromBoards
+func (game *Game) Opening() *ECOOpening {
+	return ClassifyOpeningFromBoards(game.Positions())
+}
+
+// Move plays the move on the current board. The move is matched against the legal moves by its squares and
+// promotion piece, so callers don't need to fill in the captured piece or checking squares.
+func (game *Game) Move(move *Move) error {
+	if err := game.checkInProgress("move"); err != nil {
+		return err
+	}
+	legalMove, moveErr := game.findLegalMove(move)
+	if moveErr != nil {
+		return moveErr
+	}
+	return game.pushMove(legalMove)
+}
+
+// MoveSAN plays a move given in standard algebraic notation, e.g. "Nf3"
+func (game *Game) MoveSAN(san string) error {
+	if err := game.checkInProgress("move"); err != nil {
+		return err
+	}
+	board := game.CurrentBoard()
+	move, moveErr := MoveFromAlgebraic(san, board)
+	if moveErr != nil {
+		return moveErr
+	}
+	return game.pushMove(move)
+}
+
+// MoveUCI plays a move given in UCI long algebraic notation, e.g. "g1f3"
+func (game *Game) MoveUCI(uci string) error {
+	if err := game.checkInProgress("move"); err != nil {
+		return err
+	}
+	board := game.CurrentBoard()
+	move, moveErr := MoveFromLongAlgebraic(uci, board)
+	if moveErr != nil {
+		return moveErr
+	}
+	return game.pushMove(move)
+}
+
+func (game *Game) Undo() error {
+	game.adjudicateFlag()
+	if game.outcome != nil {
+		return fmt.Errorf("cannot undo, game has ended by %s", game.outcome.Reason)
+	}
+	if game.ply == 0 {
+		return fmt.Errorf("cannot undo, no moves have been played")
+	}
+	game.ply--
+	game.syncClockTurn()
+	return nil
+}
+
+func (game *Game) Redo() error {
+	game.adjudicateFlag()
+	if game.outcome != nil {
+		return fmt.Errorf("cannot redo, game has ended by %s", game.outcome.Reason)
+	}
+	if game.ply == len(game.moves) {
+		return fmt.Errorf("cannot redo, no moves have been undone")
+	}
+	game.ply++
+	game.syncClockTurn()
+	return nil
+}
+
+func (game *Game) CanUndo() bool {
+	return game.ply > 0
+}
+
+func (game *Game) CanRedo() bool {
+	return game.ply < len(game.moves)
+}
+
+// Resign ends the game as a win for the opponent of the resigning player
+func (game *Game) Resign(isWhite bool) error {
+	if err := game.checkInProgress("resign"); err != nil {
+		return err
+	}
+	game.end(NewGameOutcome(winningGameResult(!isWhite), GAME_RESULT_REASON_RESIGNATION))
+	return nil
+}
+
+// OfferDraw stands until the opponent accepts or declines it, or until the opponent makes a move
+func (game *Game) OfferDraw(isWhite bool) error {
+	if err := game.checkInProgress("offer draw"); err != nil {
+		return err
+	}
+	game.hasDrawOffer = true
+	game.isDrawOfferedByWhite = isWhite
+	return nil
+}
+
+func (game *Game) HasDrawOffer(fromWhite bool) bool {
+	return game.hasDrawOffer && game.isDrawOfferedByWhite == fromWhite
+}
+
+func (game *Game) AcceptDraw(isWhite bool) error {
+	if err := game.checkInProgress("accept draw"); err != nil {
+		return err
+	}
+	if !game.HasDrawOffer(!isWhite) {
+		return fmt.Errorf("cannot accept draw, opponent has not offered a draw")
+	}
+	game.hasDrawOffer = false
+	game.end(NewGameOutcome(GAME_RESULT_DRAW, GAME_RESULT_REASON_DRAW_AGREEMENT))
+	return nil
+}
+
+func (game *Game) DeclineDraw(isWhite bool) error {
+	if !game.HasDrawOffer(!isWhite) {
+		return fmt.Errorf("cannot decline draw, opponent has not offered a draw")
+	}
+	game.hasDrawOffer = false
+	return nil
+}
+
+// ClaimDraw ends the game as a draw when the player to move may claim one in the current position
+func (game *Game) ClaimDraw() error {
+	if err := game.checkInProgress("claim draw"); err != nil {
+		return err
+	}
+	canClaim, result := game.CurrentBoard().CanClaimDraw()
+	if !canClaim {
+		return fmt.Errorf("cannot claim draw, neither a repetition nor the move count allows a claim")
+	}
+	game.end(GameOutcomeFromBoardResult(result))
+	return nil
+}
+
+// ClaimDrawWithMove plays the move and ends the game as a draw, for claims made on the move that creates the
+// repetition or completes the move count. If the move doesn't allow a claim, it isn't played.
+func (game *Game) ClaimDrawWithMove(move *Move) error {
+	if err := game.checkInProgress("claim draw"); err != nil {
+		return err
+	}
+	legalMove, moveErr := game.findLegalMove(move)
+	if moveErr != nil {
+		return moveErr
+	}
+	canClaim, result := game.CurrentBoard().CanClaimDrawWithMove(legalMove)
+	if !canClaim {
+		return fmt.Errorf("cannot claim draw, %s allows neither a repetition nor a move count claim", legalMove.ToLongAlgebraic())
+	}
+	if pushErr := game.pushMove(legalMove); pushErr != nil {
+		return pushErr
+	}
+	game.end(GameOutcomeFromBoardResult(result))
+	return nil
+}
+
+// Timeout ends the game as a win for the opponent of the player whose flag fell, or as a draw when the opponent
+// has no way left to checkmate
+func (game *Game) Timeout(isWhite bool) error {
+	if err := game.checkInProgress("time out"); err != nil {
+		return err
+	}
+	game.end(game.timeoutOutcome(isWhite))
+	return nil
+}
+
+// Abandon ends the game as a win for the opponent of the player who left the game
+func (game *Game) Abandon(isWhite bool) error {
+	if err := game.checkInProgress("abandon"); err != nil {
+		return err
+	}
+	game.end(NewGameOutcome(winningGameResult(!isWhite), GAME_RESULT_REASON_ABANDONMENT))
+	return nil
+}
+
+// Abort ends the game without a result, as is typical when a game is called off before it got going
+func (game *Game) Abort() error {
+	if err := game.checkInProgress("abort"); err != nil {
+		return err
+	}
+	game.end(NewGameOutcome(GAME_RESULT_ABORTED, GAME_RESULT_REASON_ABORTED))
+	return nil
+}
+
+// ToPGN writes the game's moves with the Result and Termination tags filled in from the outcome
+func (game *Game) ToPGN(tags []*PGNTag) (string, error) {
+	tree, treeErr := gameTreeFromMoves(game.boards[0], game.Moves())
+	if treeErr != nil {
+		return "", treeErr
+	}
+	outcome := game.Outcome()
+	tree.Tags = make([]*PGNTag, 0, len(tags)+1)
+	tree.Tags = append(tree.Tags, tags...)
+	tree.Tags = append(tree.Tags, &PGNTag{"Termination", outcome.ToPGNTermination()})
+	tree.Result = outcome.ToPGNResult()
+	return tree.ToPGN()
+}
+
+func (game *Game) checkInProgress(action string) error {
+	outcome := game.Outcome()
+	if outcome.IsOver() {
+		return fmt.Errorf("cannot %s, game has already ended with result %s by %s", action, outcome.Result, outcome.Reason)
+	}
+	return nil
+}
+
+// findLegalMove matches the move against the legal moves on the current board by its squares and promotion piece
+func (game *Game) findLegalMove(move *Move) (*Move, error) {
+	board := game.CurrentBoard()
+	if move.StartSquare == nil || move.EndSquare == nil || !move.StartSquare.IsValidBoardSquare() {
+		return nil, &MoveError{Err: fmt.Errorf("%w, move is missing a start or end square", ErrMalformedNotation)}
+	}
+	if board.GetPieceOnSquare(move.StartSquare) == EMPTY {
+		return nil, &MoveError{Notation: move.ToLongAlgebraic(), Square: move.StartSquare, Err: ErrNoPieceOnSquare}
+	}
+	legalMoves, movesErr := GetLegalMovesFromOrigin(board, move.StartSquare)
+	if movesErr != nil {
+		return nil, withMoveNotation(movesErr, move.ToLongAlgebraic())
+	}
+	for _, legalMove := range legalMoves {
+		if legalMove.EndSquare.Equal(move.EndSquare) && legalMove.PawnUpgradedTo == move.PawnUpgradedTo {
+			return legalMove, nil
+		}
+	}
+	return nil, &MoveError{
+		Notation:   move.ToLongAlgebraic(),
+		Square:     move.StartSquare,
+		Piece:      board.GetPieceOnSquare(move.StartSquare),
+		Candidates: legalMoves,
+		Err:        ErrIllegalMove,
+	}
+}
+
+func (game *Game) timeoutOutcome(isWhite bool) *GameOutcome {
+	if !game.CurrentBoard().HasMatingMaterial(!isWhite) {
+		return NewGameOutcome(GAME_RESULT_DRAW, GAME_RESULT_REASON_TIMEOUT_VS_INSUFFICIENT_MATERIAL)
+	}
+	return NewGameOutcome(winningGameResult(!isWhite), GAME_RESULT_REASON_TIMEOUT)
+}
+
+// adjudicateFlag ends the game by timeout once the side to move has run out of time on the clock
+func (game *Game) adjudicateFlag() {
+	if game.clock == nil || game.outcome != nil || !game.clock.HasStarted() {
+		return
+	}
+	if game.CurrentBoard().Result != BOARD_RESULT_IN_PROGRESS {
+		return
+	}
+	isWhiteTurn := game.clock.IsWhiteTurn()
+	if game.clock.IsFlagged(isWhiteTurn) {
+		game.end(game.timeoutOutcome(isWhiteTurn))
+	}
+}
+
+// syncClockTurn runs the clock for the side to move on the current board after stepping through the history
+func (game *Game) syncClockTurn() {
+	if game.clock != nil {
+		game.clock.SetTurn(game.CurrentBoard().IsWhiteTurn)
+	}
+}
+
+func (game *Game) end(outcome *GameOutcome) {
+	game.outcome = outcome
+	if game.clock != nil {
+		game.clock.Stop()
+	}
+}
+
+func winningGameResult(isWhiteWinner bool) GameResult {
+	if isWhiteWinner {
+		return GAME_RESULT_WHITE_WINS
+	}
+	return GAME_RESULT_BLACK_WINS
+}
+
+// pushMove applies an already legal move. Replaying the next undone move keeps the rest of the undone moves,
+// any other move discards them. When the game is timed, the move also presses the clock, which fails if the
+// mover has run out of time.
+func (game *Game) pushMove(move *Move) error {
+	if game.clock != nil && game.clock.HasStarted() {
+		if pressErr := game.clock.Press(); pressErr != nil {
+			game.adjudicateFlag()
+			return fmt.Errorf("cannot move %s: %w", move.ToLongAlgebraic(), pressErr)
+		}
+	}
+	if game.hasDrawOffer && game.isDrawOfferedByWhite != move.Piece.IsWhite() {
+		game.hasDrawOffer = false
+	}
+	if game.ply < len(game.moves) && game.moves[game.ply].Equal(move) {
+		game.ply++
+	} else {
+		game.boards = append(game.boards[:game.ply+1], GetBoardFromMove(game.CurrentBoard(), move))
+		game.moves = append(game.moves[:game.ply], move)
+		game.ply++
+	}
+	nextBoard := game.CurrentBoard()
+	if game.clock != nil && !game.clock.HasStarted() {
+		if startErr := game.clock.Start(nextBoard.IsWhiteTurn); startErr != nil {
+			return startErr
+		}
+	}
+	if game.clock != nil && nextBoard.Result != BOARD_RESULT_IN_PROGRESS {
+		game.clock.Stop()
+	}
+	return nil
+}
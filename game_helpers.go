@@ -433,16 +433,13 @@ func GetLegalMovesForKing(board *Board) []*Move {
 			kingMoves = append(kingMoves, &move)
 		}
 	}
-	if canCastleKingside(board, originSquare) {
-		kingDestSquare := Square{originSquare.Rank, originSquare.File + 2}
-		kingMoves = append(kingMoves, &Move{piece, originSquare, &kingDestSquare, EMPTY, make([]*Square, 0), EMPTY})
-	}
-	if canCastleQueenside(board, originSquare) {
-		kingDestSquare := Square{originSquare.Rank, originSquare.File - 2}
-		kingMoves = append(kingMoves, &Move{piece, originSquare, &kingDestSquare, EMPTY, make([]*Square, 0), EMPTY})
-	}
 	kingMoves = filterMovesByKingSafety(board, kingMoves)
 	kingMoves = filterMovesByKingCollision(board, kingMoves)
+	for _, isKingside := range []bool{true, false} {
+		if canCastle(board, isKingside) {
+			kingMoves = append(kingMoves, castleMove(board, isKingside))
+		}
+	}
 	return kingMoves
 }
 
@@ -490,7 +487,7 @@ func GetLegalMovesFromOrigin(board *Board, square *Square) ([]*Move, error) {
 		return make([]*Move, 0), nil
 	}
 	if board.IsWhiteTurn != piece.IsWhite() {
-		return nil, fmt.Errorf("cannot generate moves, not player's turn")
+		return nil, &MoveError{Square: square, Piece: piece, Err: ErrNotYourTurn}
 	}
 	if piece.IsPawn() {
 		return GetLegalMovesForPawn(board, square)
@@ -509,103 +506,129 @@ func GetLegalMovesFromOrigin(board *Board, square *Square) ([]*Move, error) {
 }
 
 func HasLegalMove(board *Board) bool {
-	for rank := uint8(1); rank < 9; rank++ {
-		for file := uint8(1); file < 9; file++ {
-			square := Square{rank, file}
-			squareMoves, _ := GetLegalMovesFromOrigin(board, &square)
-			if squareMoves != nil && len(squareMoves) > 0 {
-				return true
-			}
-		}
+	if board.IsCheckmate() {
+		return false
 	}
-	return false
+	return NewPosition(board).HasLegalMove()
 }
 
+// GetLegalMoves lists the moves of the player to move by origin square from a1 to h8, in the order
+// GetLegalMovesFromOrigin gives them for each square
 func GetLegalMoves(board *Board) ([]*Move, error) {
-	var moves = make([]*Move, 0)
 	if board.IsCheckmate() {
-		return moves, fmt.Errorf("cannot generate moves on terminal board")
-	}
-	for rank := uint8(1); rank < 9; rank++ {
-		for file := uint8(1); file < 9; file++ {
-			square := &Square{rank, file}
-			squareMoves, _ := GetLegalMovesFromOrigin(board, square)
-			if squareMoves != nil {
-				moves = append(moves, squareMoves...)
-			}
-		}
+		return make([]*Move, 0), fmt.Errorf("cannot generate moves on terminal board")
 	}
-	return moves, nil
+	return NewPosition(board).LegalMoves(), nil
 }
 
-// TODO: do we need square param here?
-func canCastleKingside(board *Board, square *Square) bool {
-	if board.IsWhiteTurn && !board.CanWhiteCastleKingside {
+// canCastle checks castling for the player to move by the Chess960 rules, which standard chess castling is a
+// special case of: every square between the king, the castling rook and their landing squares must be empty
+// apart from the king and rook themselves, and the king may not be in check on any square it passes.
+func canCastle(board *Board, isKingside bool) bool {
+	isWhite := board.IsWhiteTurn
+	if isWhite && isKingside && !board.CanWhiteCastleKingside {
 		return false
-	} else if !board.IsWhiteTurn && !board.CanBlackCastleKingside {
+	} else if isWhite && !isKingside && !board.CanWhiteCastleQueenside {
 		return false
-	}
-	if len(GetCheckingSquares(board, board.IsWhiteTurn)) > 0 {
+	} else if !isWhite && isKingside && !board.CanBlackCastleKingside {
+		return false
+	} else if !isWhite && !isKingside && !board.CanBlackCastleQueenside {
 		return false
 	}
-	piece := board.GetPieceOnSquare(square)
-
-	kingRightSquare := Square{square.Rank, square.File + 1}
-	kingRightTwoSquare := Square{square.Rank, square.File + 2}
-	kingRightPiece := board.GetPieceOnSquare(&kingRightSquare)
-	kingTwoRightPiece := board.GetPieceOnSquare(&kingRightTwoSquare)
-	if kingRightPiece != EMPTY || kingTwoRightPiece != EMPTY {
+	kingSquare := board.GetKingSquare(isWhite)
+	rookSquare := board.CastleRookSquare(isWhite, isKingside)
+	if kingSquare.Rank != rookSquare.Rank {
 		return false
 	}
-
-	boardBuilder := NewBoardBuilder().FromBoard(board)
-	boardBuilder.WithPiece(EMPTY, square)
-	boardBuilder.WithPiece(piece, &kingRightSquare)
-	if len(GetCheckingSquares(boardBuilder.board, board.IsWhiteTurn)) > 0 {
+	rookPiece := board.GetPieceOnSquare(rookSquare)
+	if !rookPiece.IsRook() || rookPiece.IsWhite() != isWhite {
 		return false
 	}
-	boardBuilder.WithPiece(EMPTY, &kingRightSquare)
-	boardBuilder.WithPiece(piece, &kingRightTwoSquare)
-	if len(GetCheckingSquares(boardBuilder.board, board.IsWhiteTurn)) > 0 {
+	if (rookSquare.File > kingSquare.File) != isKingside {
 		return false
 	}
-	return true
-}
+	kingLandSquare, rookLandSquare := castleLandSquares(isWhite, isKingside)
 
-// TODO: do we need square param here?
-func canCastleQueenside(board *Board, square *Square) bool {
-	if board.IsWhiteTurn && !board.CanWhiteCastleQueenside {
-		return false
-	} else if !board.IsWhiteTurn && !board.CanBlackCastleQueenside {
-		return false
+	minFile, maxFile := kingSquare.File, kingSquare.File
+	for _, file := range []uint8{rookSquare.File, kingLandSquare.File, rookLandSquare.File} {
+		if file < minFile {
+			minFile = file
+		}
+		if file > maxFile {
+			maxFile = file
+		}
 	}
-	if len(GetCheckingSquares(board, board.IsWhiteTurn)) > 0 {
-		return false
+	for file := minFile; file <= maxFile; file++ {
+		square := &Square{kingSquare.Rank, file}
+		if square.Equal(kingSquare) || square.Equal(rookSquare) {
+			continue
+		}
+		if board.GetPieceOnSquare(square) != EMPTY {
+			return false
+		}
 	}
-	piece := board.GetPieceOnSquare(square)
 
-	kingLeftSquare := Square{square.Rank, square.File - 1}
-	kingLeftTwoSquare := Square{square.Rank, square.File - 2}
-	kingLeftThreeSquare := Square{square.Rank, square.File - 3}
-	kingLeftPiece := board.GetPieceOnSquare(&kingLeftSquare)
-	kingTwoLeftPiece := board.GetPieceOnSquare(&kingLeftTwoSquare)
-	kingThreeLeftPiece := board.GetPieceOnSquare(&kingLeftThreeSquare)
-	if kingLeftPiece != EMPTY || kingTwoLeftPiece != EMPTY || kingThreeLeftPiece != EMPTY {
-		return false
+	// the castling rook is lifted off the board, since it may stand between the king's path and an attacker
+	boardBuilder := NewBoardBuilder().FromBoard(board)
+	boardBuilder.WithPiece(EMPTY, rookSquare)
+	boardBuilder.WithPiece(EMPTY, kingSquare)
+	enemyKingSquare := board.GetKingSquare(!isWhite)
+	prevSquare := kingSquare
+	for file := kingSquare.File; ; {
+		square := &Square{kingSquare.Rank, file}
+		boardBuilder.WithPiece(EMPTY, prevSquare)
+		boardBuilder.WithPiece(board.GetPieceOnSquare(kingSquare), square)
+		if len(GetCheckingSquares(boardBuilder.board, isWhite)) > 0 {
+			return false
+		}
+		rankDiff := int(enemyKingSquare.Rank) - int(square.Rank)
+		fileDiff := int(enemyKingSquare.File) - int(square.File)
+		if rankDiff >= -1 && rankDiff <= 1 && fileDiff >= -1 && fileDiff <= 1 {
+			return false
+		}
+		if file == kingLandSquare.File {
+			break
+		}
+		if file < kingLandSquare.File {
+			file++
+		} else {
+			file--
+		}
+		prevSquare = square
 	}
+	return true
+}
 
-	boardBuilder := NewBoardBuilder().FromBoard(board)
-	boardBuilder.WithPiece(EMPTY, square)
-	boardBuilder.WithPiece(piece, &kingLeftSquare)
-	if len(GetCheckingSquares(boardBuilder.board, board.IsWhiteTurn)) > 0 {
-		return false
+// castleMove returns the castling move for the player to move, which is written as the king moving two squares
+// in standard chess and as the king moving onto the castling rook in Chess960
+func castleMove(board *Board, isKingside bool) *Move {
+	isWhite := board.IsWhiteTurn
+	kingSquare := board.GetKingSquare(isWhite)
+	kingPiece := board.GetPieceOnSquare(kingSquare)
+	var endSquare *Square
+	if board.IsChess960 {
+		endSquare = board.CastleRookSquare(isWhite, isKingside)
+	} else {
+		endSquare, _ = castleLandSquares(isWhite, isKingside)
 	}
-	boardBuilder.WithPiece(EMPTY, &kingLeftSquare)
-	boardBuilder.WithPiece(piece, &kingLeftTwoSquare)
-	if len(GetCheckingSquares(boardBuilder.board, board.IsWhiteTurn)) > 0 {
-		return false
+	return &Move{kingPiece, kingSquare, endSquare, EMPTY, make([]*Square, 0), EMPTY}
+}
+
+// castleLandSquares returns where the king and rook end up after castling, which is the same in standard chess
+// and Chess960
+func castleLandSquares(isWhite bool, isKingside bool) (*Square, *Square) {
+	rank := backRank(isWhite)
+	if isKingside {
+		return &Square{rank, 7}, &Square{rank, 6}
 	}
-	return true
+	return &Square{rank, 3}, &Square{rank, 4}
+}
+
+func backRank(isWhite bool) uint8 {
+	if isWhite {
+		return 1
+	}
+	return 8
 }
 
 func GetBoardFromMove(board *Board, move *Move) *Board {
@@ -619,7 +642,7 @@ func GetBoardFromMove(board *Board, move *Move) *Board {
 
 	boardBuilder.WithIsWhiteTurn(!board.IsWhiteTurn)
 
-	repetitions := UpdateRepetitionsByFENMap(board, boardBuilder, move)
+	repetitions := UpdateRepetitionsByKey(board, boardBuilder, move)
 	UpdateBoardResult(board, boardBuilder, repetitions)
 
 	return boardBuilder.Build()
@@ -627,6 +650,18 @@ func GetBoardFromMove(board *Board, move *Move) *Board {
 
 func UpdatePiecesFromMove(lastBoard *Board, boardBuilder *BoardBuilder, move *Move) {
 	movingPiece := lastBoard.GetPieceOnSquare(move.StartSquare)
+	if move.IsCastlesOn(lastBoard) {
+		isWhite := movingPiece.IsWhite()
+		isKingside := move.EndSquare.File > move.StartSquare.File
+		rookSquare := lastBoard.CastleRookSquare(isWhite, isKingside)
+		rookPiece := lastBoard.GetPieceOnSquare(rookSquare)
+		kingLandSquare, rookLandSquare := castleLandSquares(isWhite, isKingside)
+		boardBuilder.WithPiece(EMPTY, move.StartSquare)
+		boardBuilder.WithPiece(EMPTY, rookSquare)
+		boardBuilder.WithPiece(movingPiece, kingLandSquare)
+		boardBuilder.WithPiece(rookPiece, rookLandSquare)
+		return
+	}
 	var landingPiece Piece
 	if move.PawnUpgradedTo != EMPTY {
 		landingPiece = move.PawnUpgradedTo
@@ -643,26 +678,6 @@ func UpdatePiecesFromMove(lastBoard *Board, boardBuilder *BoardBuilder, move *Mo
 		}
 		boardBuilder.WithPiece(EMPTY, &enPassantedPawnSquare)
 	}
-
-	if move.IsCastles() {
-		if move.Piece == WHITE_KING {
-			if move.EndSquare.Equal(&Square{1, 7}) {
-				boardBuilder.WithPiece(EMPTY, &Square{1, 8})
-				boardBuilder.WithPiece(WHITE_ROOK, &Square{1, 6})
-			} else if move.EndSquare.Equal(&Square{1, 3}) {
-				boardBuilder.WithPiece(EMPTY, &Square{1, 1})
-				boardBuilder.WithPiece(WHITE_ROOK, &Square{1, 4})
-			}
-		} else if move.Piece == BLACK_KING {
-			if move.EndSquare.Equal(&Square{8, 7}) {
-				boardBuilder.WithPiece(EMPTY, &Square{8, 8})
-				boardBuilder.WithPiece(BLACK_ROOK, &Square{8, 6})
-			} else if move.EndSquare.Equal(&Square{8, 3}) {
-				boardBuilder.WithPiece(EMPTY, &Square{8, 1})
-				boardBuilder.WithPiece(BLACK_ROOK, &Square{8, 4})
-			}
-		}
-	}
 }
 
 func UpdateBoardCounters(lastBoard *Board, boardBuilder *BoardBuilder, move *Move) {
@@ -673,7 +688,7 @@ func UpdateBoardCounters(lastBoard *Board, boardBuilder *BoardBuilder, move *Mov
 	isHalfMoveClockReset := move.CapturedPiece != EMPTY || move.Piece.IsPawn() || isCastleRightsUpdated
 	if isHalfMoveClockReset {
 		boardBuilder.WithHalfMoveClockCount(0)
-	} else {
+	} else if lastBoard.HalfMoveClockCount < math.MaxUint8 {
 		boardBuilder.WithHalfMoveClockCount(lastBoard.HalfMoveClockCount + 1)
 	}
 
@@ -695,13 +710,13 @@ func UpdateBoardEnPassantSquare(lastBoard *Board, boardBuilder *BoardBuilder, mo
 }
 
 func UpdateCastleRights(lastBoard *Board, boardBuilder *BoardBuilder, move *Move) {
-	if move.EndSquare.Equal(&Square{8, 1}) {
+	if move.EndSquare.Equal(lastBoard.CastleRookSquare(false, false)) {
 		boardBuilder.WithCanBlackCastleQueenside(false)
-	} else if move.EndSquare.Equal(&Square{8, 8}) {
+	} else if move.EndSquare.Equal(lastBoard.CastleRookSquare(false, true)) {
 		boardBuilder.WithCanBlackCastleKingside(false)
-	} else if move.EndSquare.Equal(&Square{1, 1}) {
+	} else if move.EndSquare.Equal(lastBoard.CastleRookSquare(true, false)) {
 		boardBuilder.WithCanWhiteCastleQueenside(false)
-	} else if move.EndSquare.Equal(&Square{1, 8}) {
+	} else if move.EndSquare.Equal(lastBoard.CastleRookSquare(true, true)) {
 		boardBuilder.WithCanWhiteCastleKingside(false)
 	}
 
@@ -709,13 +724,13 @@ func UpdateCastleRights(lastBoard *Board, boardBuilder *BoardBuilder, move *Move
 		return
 	}
 	if move.Piece.IsRook() {
-		if move.StartSquare.Equal(&Square{1, 1}) {
+		if move.StartSquare.Equal(lastBoard.CastleRookSquare(true, false)) {
 			boardBuilder.WithCanWhiteCastleQueenside(false)
-		} else if move.StartSquare.Equal(&Square{1, 8}) {
+		} else if move.StartSquare.Equal(lastBoard.CastleRookSquare(true, true)) {
 			boardBuilder.WithCanWhiteCastleKingside(false)
-		} else if move.StartSquare.Equal(&Square{8, 1}) {
+		} else if move.StartSquare.Equal(lastBoard.CastleRookSquare(false, false)) {
 			boardBuilder.WithCanBlackCastleQueenside(false)
-		} else if move.StartSquare.Equal(&Square{8, 8}) {
+		} else if move.StartSquare.Equal(lastBoard.CastleRookSquare(false, true)) {
 			boardBuilder.WithCanBlackCastleKingside(false)
 		}
 	} else if move.Piece.IsKing() {
@@ -729,7 +744,9 @@ func UpdateCastleRights(lastBoard *Board, boardBuilder *BoardBuilder, move *Move
 	}
 }
 
-func UpdateRepetitionsByFENMap(lastBoard *Board, boardBuilder *BoardBuilder, move *Move) uint8 {
+// UpdateRepetitionsByKey counts the position reached by the move, keyed by its Zobrist key. Positions from before
+// an irreversible move can't recur, so their counts are dropped.
+func UpdateRepetitionsByKey(lastBoard *Board, boardBuilder *BoardBuilder, move *Move) uint8 {
 	castleRightsChanged := false
 	castleRightsChanged = castleRightsChanged || lastBoard.CanWhiteCastleKingside != boardBuilder.board.CanWhiteCastleKingside
 	castleRightsChanged = castleRightsChanged || lastBoard.CanWhiteCastleQueenside != boardBuilder.board.CanWhiteCastleQueenside
@@ -737,19 +754,25 @@ func UpdateRepetitionsByFENMap(lastBoard *Board, boardBuilder *BoardBuilder, mov
 	castleRightsChanged = castleRightsChanged || lastBoard.CanBlackCastleQueenside != boardBuilder.board.CanBlackCastleQueenside
 
 	if move.Piece.IsPawn() || move.CapturedPiece != EMPTY || castleRightsChanged {
-		boardBuilder.WithRepetitionsByMiniFEN(make(map[string]uint8))
+		boardBuilder.WithRepetitionsByKey(make(map[uint64]uint8))
 	}
-	miniFEN := boardBuilder.board.ToMiniFEN()
-	repetitions, _ := lastBoard.RepetitionsByMiniFEN[miniFEN]
-	boardBuilder.WithMiniFENCount(miniFEN, repetitions+1)
+	key := boardBuilder.board.ZobristKey()
+	repetitions := lastBoard.RepetitionsByKey[key]
+	boardBuilder.WithKeyCount(key, repetitions+1)
 	return repetitions + 1
 }
 
+// Deprecated: use UpdateRepetitionsByKey, which this now calls
+func UpdateRepetitionsByFENMap(lastBoard *Board, boardBuilder *BoardBuilder, move *Move) uint8 {
+	return UpdateRepetitionsByKey(lastBoard, boardBuilder, move)
+}
+
+// UpdateBoardResult applies the automatic draw rules of the board's Rules, claimable draws are left to the players
+// (see Board.CanClaimDraw). A checkmate delivered on the move that reaches the automatic move count still stands.
 func UpdateBoardResult(lastBoard *Board, boardBuilder *BoardBuilder, repetitions uint8) {
-	if repetitions >= 3 {
-		boardBuilder.WithResult(BOARD_RESULT_DRAW_BY_THREEFOLD_REPETITION)
-	} else if boardBuilder.board.HalfMoveClockCount >= 50 {
-		boardBuilder.WithResult(BOARD_RESULT_DRAW_BY_FIFTY_MOVE_RULE)
+	rules := boardBuilder.board.Rules()
+	if rules.IsAutoDrawByRepetition(repetitions) {
+		boardBuilder.WithResult(repetitionDrawResult(rules.AutoRepetitions))
 	} else if !boardBuilder.board.HasLegalNextMove() {
 		checkingSquares := GetCheckingSquares(boardBuilder.board, boardBuilder.board.IsWhiteTurn)
 		if len(checkingSquares) > 0 {
@@ -761,7 +784,9 @@ func UpdateBoardResult(lastBoard *Board, boardBuilder *BoardBuilder, repetitions
 		} else {
 			boardBuilder.WithResult(BOARD_RESULT_DRAW_BY_STALEMATE)
 		}
-	} else if boardBuilder.board.IsForcedDrawByMaterial() {
+	} else if rules.IsAutoDrawByHalfMoves(boardBuilder.board.HalfMoveClockCount) {
+		boardBuilder.WithResult(halfMoveDrawResult(rules.AutoHalfMoves))
+	} else if rules.IsDrawByInsufficientMaterial(boardBuilder.board) {
 		boardBuilder.WithResult(BOARD_RESULT_DRAW_BY_INSUFFICIENT_MATERIAL)
 	}
 }
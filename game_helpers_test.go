@@ -9,6 +9,16 @@ import (
 	. "github.com/CameronHonis/chess"
 )
 
+// UNRELATED_KEY stands for a position from earlier in the game than the boards under test
+const UNRELATED_KEY = uint64(12345)
+
+// keyFromMiniFEN returns the Zobrist key of the position of a FEN without its move counters
+func keyFromMiniFEN(miniFEN string) uint64 {
+	board, err := BoardFromFEN(miniFEN + " 0 1")
+	Expect(err).ToNot(HaveOccurred())
+	return board.ZobristKey()
+}
+
 func compareSquares(expSquares []Square, realSquares []*Square) {
 	Expect(realSquares).To(HaveLen(len(expSquares)))
 	for _, realSquare := range realSquares {
@@ -1041,7 +1051,7 @@ var _ = Describe("GameHelpers", func() {
 		When("the move is a capture", func() {
 			BeforeEach(func() {
 				initBoard, _ := BoardFromFEN("k7/p2n4/8/8/6B1/8/8/7K w - - 0 1")
-				initBoard.RepetitionsByMiniFEN["asdf"] = 2
+				initBoard.RepetitionsByKey[UNRELATED_KEY] = 2
 				move = Move{WHITE_BISHOP, &Square{4, 7}, &Square{7, 4}, BLACK_KNIGHT, make([]*Square, 0), EMPTY}
 				board = GetBoardFromMove(initBoard, &move)
 			})
@@ -1052,14 +1062,13 @@ var _ = Describe("GameHelpers", func() {
 				Expect(board.GetPieceOnSquare(&Square{4, 7})).To(Equal(EMPTY))
 				Expect(board.GetPieceOnSquare(&Square{7, 4})).To(Equal(WHITE_BISHOP))
 			})
-			It("adds the miniFEN to the board counter map", func() {
-				miniFEN := "k7/p2B4/8/8/8/8/8/7K b - -"
-				miniFENRepetitions, ok := board.RepetitionsByMiniFEN[miniFEN]
+			It("adds the position key to the board counter map", func() {
+				repetitions, ok := board.RepetitionsByKey[keyFromMiniFEN("k7/p2B4/8/8/8/8/8/7K b - -")]
 				Expect(ok).To(BeTrue())
-				Expect(miniFENRepetitions).To(Equal(uint8(1)))
+				Expect(repetitions).To(Equal(uint8(1)))
 			})
 			It("clears the repetition counter map", func() {
-				_, ok := board.RepetitionsByMiniFEN["asdf"]
+				_, ok := board.RepetitionsByKey[UNRELATED_KEY]
 				Expect(ok).To(BeFalse())
 			})
 			When("the capture is an en passant move", func() {
@@ -1088,7 +1097,7 @@ var _ = Describe("GameHelpers", func() {
 		When("the move is not a capture", func() {
 			BeforeEach(func() {
 				initBoard, _ := BoardFromFEN("k7/4r3/8/8/8/8/5N2/7K w - - 0 1")
-				initBoard.RepetitionsByMiniFEN["asdf"] = 2
+				initBoard.RepetitionsByKey[UNRELATED_KEY] = 2
 				move = Move{WHITE_KNIGHT, &Square{2, 6}, &Square{3, 4}, EMPTY, make([]*Square, 0), EMPTY}
 				board = GetBoardFromMove(initBoard, &move)
 			})
@@ -1099,16 +1108,15 @@ var _ = Describe("GameHelpers", func() {
 			It("increments the half move counter", func() {
 				Expect(board.HalfMoveClockCount).To(Equal(uint8(1)))
 			})
-			It("adds the miniFEN to the repetition counter map", func() {
-				miniFEN := "k7/4r3/8/8/8/3N4/8/7K b - -"
-				miniFENRepetitions, ok := board.RepetitionsByMiniFEN[miniFEN]
+			It("adds the position key to the repetition counter map", func() {
+				repetitions, ok := board.RepetitionsByKey[keyFromMiniFEN("k7/4r3/8/8/8/3N4/8/7K b - -")]
 				Expect(ok).To(BeTrue())
-				Expect(miniFENRepetitions).To(Equal(uint8(1)))
+				Expect(repetitions).To(Equal(uint8(1)))
 			})
 			It("does not clear the repetition counter map", func() {
-				miniFENRepetitions, ok := board.RepetitionsByMiniFEN["asdf"]
+				repetitions, ok := board.RepetitionsByKey[UNRELATED_KEY]
 				Expect(ok).To(BeTrue())
-				Expect(miniFENRepetitions).To(Equal(uint8(2)))
+				Expect(repetitions).To(Equal(uint8(2)))
 			})
 			Context("and the pawn is being upgraded", func() {
 				BeforeEach(func() {
@@ -1167,7 +1175,7 @@ var _ = Describe("GameHelpers", func() {
 			When("the rook is white", func() {
 				BeforeEach(func() {
 					board, _ = BoardFromFEN("r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1")
-					board.RepetitionsByMiniFEN["asdf"] = 2
+					board.RepetitionsByKey[UNRELATED_KEY] = 2
 					Expect(board.CanWhiteCastleKingside).To(BeTrue())
 					Expect(board.CanWhiteCastleQueenside).To(BeTrue())
 				})
@@ -1180,7 +1188,7 @@ var _ = Describe("GameHelpers", func() {
 						Expect(board.CanWhiteCastleKingside).To(BeFalse())
 					})
 					It("clears the repetition counter map", func() {
-						_, ok := board.RepetitionsByMiniFEN["asdf"]
+						_, ok := board.RepetitionsByKey[UNRELATED_KEY]
 						Expect(ok).To(BeFalse())
 					})
 				})
@@ -1193,7 +1201,7 @@ var _ = Describe("GameHelpers", func() {
 						Expect(board.CanWhiteCastleQueenside).To(BeFalse())
 					})
 					It("clears the repetition counter map", func() {
-						_, ok := board.RepetitionsByMiniFEN["asdf"]
+						_, ok := board.RepetitionsByKey[UNRELATED_KEY]
 						Expect(ok).To(BeFalse())
 					})
 				})
@@ -1201,7 +1209,7 @@ var _ = Describe("GameHelpers", func() {
 			When("the rook is black", func() {
 				BeforeEach(func() {
 					board, _ = BoardFromFEN("r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1")
-					board.RepetitionsByMiniFEN["asdf"] = 2
+					board.RepetitionsByKey[UNRELATED_KEY] = 2
 				})
 				Context("and the rook is the kingside rook", func() {
 					BeforeEach(func() {
@@ -1213,7 +1221,7 @@ var _ = Describe("GameHelpers", func() {
 						Expect(board.CanBlackCastleKingside).To(BeFalse())
 					})
 					It("clears the repetition counter map", func() {
-						_, ok := board.RepetitionsByMiniFEN["asdf"]
+						_, ok := board.RepetitionsByKey[UNRELATED_KEY]
 						Expect(ok).To(BeFalse())
 					})
 				})
@@ -1227,7 +1235,7 @@ var _ = Describe("GameHelpers", func() {
 						Expect(board.CanBlackCastleQueenside).To(BeFalse())
 					})
 					It("clears the repetition counter map", func() {
-						_, ok := board.RepetitionsByMiniFEN["asdf"]
+						_, ok := board.RepetitionsByKey[UNRELATED_KEY]
 						Expect(ok).To(BeFalse())
 					})
 				})
@@ -1237,7 +1245,7 @@ var _ = Describe("GameHelpers", func() {
 			Context("the king is white", func() {
 				BeforeEach(func() {
 					board, _ = BoardFromFEN("r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1")
-					board.RepetitionsByMiniFEN["asdf"] = 2
+					board.RepetitionsByKey[UNRELATED_KEY] = 2
 					Expect(board.CanWhiteCastleKingside).To(BeTrue())
 					Expect(board.CanWhiteCastleQueenside).To(BeTrue())
 					move = Move{WHITE_KING, &Square{1, 5}, &Square{2, 4}, EMPTY, make([]*Square, 0), EMPTY}
@@ -1248,7 +1256,7 @@ var _ = Describe("GameHelpers", func() {
 					Expect(board.CanWhiteCastleQueenside).To(BeFalse())
 				})
 				It("clears the repetition counter map", func() {
-					_, ok := board.RepetitionsByMiniFEN["asdf"]
+					_, ok := board.RepetitionsByKey[UNRELATED_KEY]
 					Expect(ok).To(BeFalse())
 				})
 			})
@@ -1265,7 +1273,7 @@ var _ = Describe("GameHelpers", func() {
 					Expect(board.CanBlackCastleQueenside).To(BeFalse())
 				})
 				It("clears the repetition counter map", func() {
-					_, ok := board.RepetitionsByMiniFEN["asdf"]
+					_, ok := board.RepetitionsByKey[UNRELATED_KEY]
 					Expect(ok).To(BeFalse())
 				})
 			})
@@ -1274,7 +1282,7 @@ var _ = Describe("GameHelpers", func() {
 			Context("and white castles kingside", func() {
 				BeforeEach(func() {
 					board, _ = BoardFromFEN("r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1")
-					board.RepetitionsByMiniFEN["asdf"] = 2
+					board.RepetitionsByKey[UNRELATED_KEY] = 2
 					move = Move{WHITE_KING, &Square{1, 5}, &Square{1, 7}, EMPTY, make([]*Square, 0), EMPTY}
 					Expect(board.GetPieceOnSquare(&Square{1, 5})).To(Equal(WHITE_KING))
 					Expect(board.GetPieceOnSquare(&Square{1, 6})).To(Equal(EMPTY))
@@ -1295,15 +1303,14 @@ var _ = Describe("GameHelpers", func() {
 				})
 				It("clears the repetition counter map", func() {
 					board = GetBoardFromMove(board, &move)
-					_, ok := board.RepetitionsByMiniFEN["asdf"]
+					_, ok := board.RepetitionsByKey[UNRELATED_KEY]
 					Expect(ok).To(BeFalse())
 				})
-				It("adds the current miniFEN to the repetition counter map", func() {
+				It("adds the current position key to the repetition counter map", func() {
 					board = GetBoardFromMove(board, &move)
-					miniFEN := board.ToMiniFEN()
-					miniFENRepetitions, ok := board.RepetitionsByMiniFEN[miniFEN]
+					repetitions, ok := board.RepetitionsByKey[board.ZobristKey()]
 					Expect(ok).To(BeTrue())
-					Expect(miniFENRepetitions).To(Equal(uint8(1)))
+					Expect(repetitions).To(Equal(uint8(1)))
 				})
 			})
 			Context("and white castles queenside", func() {
@@ -1329,15 +1336,14 @@ var _ = Describe("GameHelpers", func() {
 				})
 				It("clears the repetition counter map", func() {
 					board = GetBoardFromMove(board, &move)
-					_, ok := board.RepetitionsByMiniFEN["asdf"]
+					_, ok := board.RepetitionsByKey[UNRELATED_KEY]
 					Expect(ok).To(BeFalse())
 				})
-				It("adds the current miniFEN to the repetition counter map", func() {
+				It("adds the current position key to the repetition counter map", func() {
 					board = GetBoardFromMove(board, &move)
-					miniFEN := board.ToMiniFEN()
-					miniFENRepetitions, ok := board.RepetitionsByMiniFEN[miniFEN]
+					repetitions, ok := board.RepetitionsByKey[board.ZobristKey()]
 					Expect(ok).To(BeTrue())
-					Expect(miniFENRepetitions).To(Equal(uint8(1)))
+					Expect(repetitions).To(Equal(uint8(1)))
 				})
 			})
 			Context("and black castles kingside", func() {
@@ -1363,15 +1369,14 @@ var _ = Describe("GameHelpers", func() {
 				})
 				It("clears the repetition counter map", func() {
 					board = GetBoardFromMove(board, &move)
-					_, ok := board.RepetitionsByMiniFEN["asdf"]
+					_, ok := board.RepetitionsByKey[UNRELATED_KEY]
 					Expect(ok).To(BeFalse())
 				})
-				It("adds the current miniFEN to the repetition counter map", func() {
+				It("adds the current position key to the repetition counter map", func() {
 					board = GetBoardFromMove(board, &move)
-					miniFEN := board.ToMiniFEN()
-					miniFENRepetitions, ok := board.RepetitionsByMiniFEN[miniFEN]
+					repetitions, ok := board.RepetitionsByKey[board.ZobristKey()]
 					Expect(ok).To(BeTrue())
-					Expect(miniFENRepetitions).To(Equal(uint8(1)))
+					Expect(repetitions).To(Equal(uint8(1)))
 				})
 			})
 			Context("and black castles queenside", func() {
@@ -1397,15 +1402,14 @@ var _ = Describe("GameHelpers", func() {
 				})
 				It("clears the repetition counter map", func() {
 					board = GetBoardFromMove(board, &move)
-					_, ok := board.RepetitionsByMiniFEN["asdf"]
+					_, ok := board.RepetitionsByKey[UNRELATED_KEY]
 					Expect(ok).To(BeFalse())
 				})
-				It("adds the current miniFEN to the repetition counter map", func() {
+				It("adds the current position key to the repetition counter map", func() {
 					board = GetBoardFromMove(board, &move)
-					miniFEN := board.ToMiniFEN()
-					miniFENRepetitions, ok := board.RepetitionsByMiniFEN[miniFEN]
+					repetitions, ok := board.RepetitionsByKey[board.ZobristKey()]
 					Expect(ok).To(BeTrue())
-					Expect(miniFENRepetitions).To(Equal(uint8(1)))
+					Expect(repetitions).To(Equal(uint8(1)))
 				})
 			})
 		})
@@ -1413,18 +1417,31 @@ var _ = Describe("GameHelpers", func() {
 			BeforeEach(func() {
 				board, _ = BoardFromFEN("8/8/4k3/8/3K4/4P3/8/8 w - - 0 1")
 				move = Move{WHITE_KING, &Square{4, 4}, &Square{4, 5}, EMPTY, make([]*Square, 0), EMPTY}
-				board.RepetitionsByMiniFEN["8/8/4k3/8/4K3/4P3/8/8 b - -"] = 2
+				board.RepetitionsByKey[keyFromMiniFEN("8/8/4k3/8/4K3/4P3/8/8 b - -")] = 2
 			})
 			It("increments the board repetitions counter map", func() {
 				board = GetBoardFromMove(board, &move)
-				miniFEN := "8/8/4k3/8/4K3/4P3/8/8 b - -"
-				miniFENRepetitions, ok := board.RepetitionsByMiniFEN[miniFEN]
+				repetitions, ok := board.RepetitionsByKey[keyFromMiniFEN("8/8/4k3/8/4K3/4P3/8/8 b - -")]
 				Expect(ok).To(BeTrue())
-				Expect(miniFENRepetitions).To(Equal(uint8(3)))
+				Expect(repetitions).To(Equal(uint8(3)))
+			})
+			It("results in a claimable draw", func() {
+				board = GetBoardFromMove(board, &move)
+				Expect(board.Result).To(Equal(BOARD_RESULT_IN_PROGRESS))
+				canClaim, result := board.CanClaimDraw()
+				Expect(canClaim).To(BeTrue())
+				Expect(result).To(Equal(BOARD_RESULT_DRAW_BY_THREEFOLD_REPETITION))
+			})
+		})
+		When("the resulting board is repeated 5 times", func() {
+			BeforeEach(func() {
+				board, _ = BoardFromFEN("8/8/4k3/8/3K4/4P3/8/8 w - - 0 1")
+				move = Move{WHITE_KING, &Square{4, 4}, &Square{4, 5}, EMPTY, make([]*Square, 0), EMPTY}
+				board.RepetitionsByKey[keyFromMiniFEN("8/8/4k3/8/4K3/4P3/8/8 b - -")] = 4
 			})
 			It("results in a terminal draw state", func() {
 				board = GetBoardFromMove(board, &move)
-				Expect(board.Result).To(Equal(BOARD_RESULT_DRAW_BY_THREEFOLD_REPETITION))
+				Expect(board.Result).To(Equal(BOARD_RESULT_DRAW_BY_FIVEFOLD_REPETITION))
 			})
 		})
 		When("the resulting board is a stalemate", func() {
@@ -1437,15 +1454,39 @@ var _ = Describe("GameHelpers", func() {
 				Expect(board.Result).To(Equal(BOARD_RESULT_DRAW_BY_STALEMATE))
 			})
 		})
-		When("the move violates the 50-move rule", func() {
+		When("the move completes 50 moves without a capture or pawn move", func() {
+			BeforeEach(func() {
+				board = GetInitBoard()
+				board.HalfMoveClockCount = 99
+				move = Move{WHITE_KNIGHT, &Square{1, 2}, &Square{3, 3}, EMPTY, make([]*Square, 0), EMPTY}
+			})
+			It("results in a claimable draw", func() {
+				board = GetBoardFromMove(board, &move)
+				Expect(board.Result).To(Equal(BOARD_RESULT_IN_PROGRESS))
+				canClaim, result := board.CanClaimDraw()
+				Expect(canClaim).To(BeTrue())
+				Expect(result).To(Equal(BOARD_RESULT_DRAW_BY_FIFTY_MOVE_RULE))
+			})
+		})
+		When("the move violates the 75-move rule", func() {
 			BeforeEach(func() {
 				board = GetInitBoard()
-				board.HalfMoveClockCount = 49
+				board.HalfMoveClockCount = 149
 				move = Move{WHITE_KNIGHT, &Square{1, 2}, &Square{3, 3}, EMPTY, make([]*Square, 0), EMPTY}
 			})
 			It("results in a terminal draw board", func() {
 				board = GetBoardFromMove(board, &move)
-				Expect(board.Result).To(Equal(BOARD_RESULT_DRAW_BY_FIFTY_MOVE_RULE))
+				Expect(board.Result).To(Equal(BOARD_RESULT_DRAW_BY_SEVENTY_FIVE_MOVE_RULE))
+			})
+		})
+		When("the move delivers checkmate on the 75th move", func() {
+			BeforeEach(func() {
+				board, _ = BoardFromFEN("k7/8/1K6/8/8/8/8/7R w - - 149 100")
+				move = Move{WHITE_ROOK, &Square{1, 8}, &Square{8, 8}, EMPTY, []*Square{{8, 8}}, EMPTY}
+			})
+			It("results in checkmate", func() {
+				board = GetBoardFromMove(board, &move)
+				Expect(board.Result).To(Equal(BOARD_RESULT_WHITE_WINS_BY_CHECKMATE))
 			})
 		})
 		When("the remaining material forces a draw", func() {
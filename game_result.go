@@ -0,0 +1,97 @@
+package chess
+
+type GameResult string
+
+const (
+	GAME_RESULT_IN_PROGRESS GameResult = "in_progress"
+	GAME_RESULT_WHITE_WINS  GameResult = "white_wins"
+	GAME_RESULT_BLACK_WINS  GameResult = "black_wins"
+	GAME_RESULT_DRAW        GameResult = "draw"
+	GAME_RESULT_ABORTED     GameResult = "aborted"
+)
+
+type GameResultReason string
+
+const (
+	GAME_RESULT_REASON_NONE                   GameResultReason = ""
+	GAME_RESULT_REASON_CHECKMATE              GameResultReason = "checkmate"
+	GAME_RESULT_REASON_STALEMATE              GameResultReason = "stalemate"
+	GAME_RESULT_REASON_INSUFFICIENT_MATERIAL  GameResultReason = "insufficient_material"
+	GAME_RESULT_REASON_THREEFOLD_REPETITION   GameResultReason = "threefold_repetition"
+	GAME_RESULT_REASON_FIFTY_MOVE_RULE        GameResultReason = "fifty_move_rule"
+	GAME_RESULT_REASON_FIVEFOLD_REPETITION    GameResultReason = "fivefold_repetition"
+	GAME_RESULT_REASON_SEVENTY_FIVE_MOVE_RULE GameResultReason = "seventy_five_move_rule"
+	GAME_RESULT_REASON_RESIGNATION            GameResultReason = "resignation"
+	GAME_RESULT_REASON_DRAW_AGREEMENT         GameResultReason = "draw_agreement"
+	GAME_RESULT_REASON_TIMEOUT                GameResultReason = "timeout"
+	// GAME_RESULT_REASON_TIMEOUT_VS_INSUFFICIENT_MATERIAL is a flag fall against a player that can't possibly mate
+	GAME_RESULT_REASON_TIMEOUT_VS_INSUFFICIENT_MATERIAL GameResultReason = "timeout_vs_insufficient_material"
+	GAME_RESULT_REASON_ABANDONMENT                      GameResultReason = "abandonment"
+	GAME_RESULT_REASON_ABORTED                          GameResultReason = "aborted"
+)
+
+// GameOutcome is the result of a whole game, covering both results derived from the board and events that
+// happen off the board, like resignations and flag falls.
+type GameOutcome struct {
+	Result GameResult       `json:"result"`
+	Reason GameResultReason `json:"reason"`
+}
+
+func NewGameOutcome(result GameResult, reason GameResultReason) *GameOutcome {
+	return &GameOutcome{result, reason}
+}
+
+func GameOutcomeFromBoardResult(result BoardResult) *GameOutcome {
+	switch result {
+	case BOARD_RESULT_WHITE_WINS_BY_CHECKMATE:
+		return NewGameOutcome(GAME_RESULT_WHITE_WINS, GAME_RESULT_REASON_CHECKMATE)
+	case BOARD_RESULT_BLACK_WINS_BY_CHECKMATE:
+		return NewGameOutcome(GAME_RESULT_BLACK_WINS, GAME_RESULT_REASON_CHECKMATE)
+	case BOARD_RESULT_DRAW_BY_STALEMATE:
+		return NewGameOutcome(GAME_RESULT_DRAW, GAME_RESULT_REASON_STALEMATE)
+	case BOARD_RESULT_DRAW_BY_INSUFFICIENT_MATERIAL:
+		return NewGameOutcome(GAME_RESULT_DRAW, GAME_RESULT_REASON_INSUFFICIENT_MATERIAL)
+	case BOARD_RESULT_DRAW_BY_THREEFOLD_REPETITION:
+		return NewGameOutcome(GAME_RESULT_DRAW, GAME_RESULT_REASON_THREEFOLD_REPETITION)
+	case BOARD_RESULT_DRAW_BY_FIFTY_MOVE_RULE:
+		return NewGameOutcome(GAME_RESULT_DRAW, GAME_RESULT_REASON_FIFTY_MOVE_RULE)
+	case BOARD_RESULT_DRAW_BY_FIVEFOLD_REPETITION:
+		return NewGameOutcome(GAME_RESULT_DRAW, GAME_RESULT_REASON_FIVEFOLD_REPETITION)
+	case BOARD_RESULT_DRAW_BY_SEVENTY_FIVE_MOVE_RULE:
+		return NewGameOutcome(GAME_RESULT_DRAW, GAME_RESULT_REASON_SEVENTY_FIVE_MOVE_RULE)
+	default:
+		return NewGameOutcome(GAME_RESULT_IN_PROGRESS, GAME_RESULT_REASON_NONE)
+	}
+}
+
+func (outcome *GameOutcome) IsOver() bool {
+	return outcome.Result != GAME_RESULT_IN_PROGRESS
+}
+
+// ToPGNResult returns the game termination marker used in both the movetext and the Result tag
+func (outcome *GameOutcome) ToPGNResult() string {
+	switch outcome.Result {
+	case GAME_RESULT_WHITE_WINS:
+		return "1-0"
+	case GAME_RESULT_BLACK_WINS:
+		return "0-1"
+	case GAME_RESULT_DRAW:
+		return "1/2-1/2"
+	default:
+		return "*"
+	}
+}
+
+// ToPGNTermination returns the value of the PGN Termination tag, as described by the PGN standard
+func (outcome *GameOutcome) ToPGNTermination() string {
+	switch outcome.Reason {
+	case GAME_RESULT_REASON_NONE:
+		return "unterminated"
+	case GAME_RESULT_REASON_TIMEOUT, GAME_RESULT_REASON_TIMEOUT_VS_INSUFFICIENT_MATERIAL:
+		return "time forfeit"
+	case GAME_RESULT_REASON_ABANDONMENT, GAME_RESULT_REASON_ABORTED:
+		return "abandoned"
+	default:
+		return "normal"
+	}
+}
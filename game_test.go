@@ -0,0 +1,271 @@
+package chess_test
+
+import (
+	"encoding/json"
+
+	. "github.com/CameronHonis/chess"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Game", func() {
+	var game *Game
+	BeforeEach(func() {
+		game = NewGame()
+	})
+
+	Describe("::Move", func() {
+		It("plays a legal move, filling in the move details", func() {
+			move := &Move{Piece: WHITE_PAWN, StartSquare: &Square{2, 5}, EndSquare: &Square{4, 5}}
+			Expect(game.Move(move)).To(Succeed())
+			Expect(game.Moves()).To(HaveLen(1))
+			Expect(game.CurrentBoard().IsWhiteTurn).To(BeFalse())
+		})
+		It("rejects an illegal move", func() {
+			move := &Move{Piece: WHITE_PAWN, StartSquare: &Square{2, 5}, EndSquare: &Square{5, 5}}
+			Expect(game.Move(move)).ToNot(Succeed())
+			Expect(game.Moves()).To(BeEmpty())
+		})
+		It("rejects moves once the game has ended", func() {
+			for _, san := range []string{"f3", "e5", "g4", "Qh4"} {
+				Expect(game.MoveSAN(san)).To(Succeed())
+			}
+			Expect(game.CurrentBoard().Result).To(Equal(BOARD_RESULT_BLACK_WINS_BY_CHECKMATE))
+			Expect(game.MoveSAN("a3")).ToNot(Succeed())
+		})
+	})
+
+	Describe("::MoveSAN", func() {
+		It("plays the move", func() {
+			Expect(game.MoveSAN("Nf3")).To(Succeed())
+			Expect(game.CurrentBoard().GetPieceOnSquare(&Square{3, 6})).To(Equal(WHITE_KNIGHT))
+		})
+	})
+
+	Describe("::MoveUCI", func() {
+		It("plays the move", func() {
+			Expect(game.MoveUCI("g1f3")).To(Succeed())
+			Expect(game.CurrentBoard().GetPieceOnSquare(&Square{3, 6})).To(Equal(WHITE_KNIGHT))
+		})
+	})
+
+	Describe("::Opening", func() {
+		It("classifies the moves played so far", func() {
+			Expect(game.Opening()).To(BeNil())
+			for _, san := range []string{"e4", "e5", "Nf3", "Nc6", "d4", "exd4", "Nxd4", "Qh4"} {
+				Expect(game.MoveSAN(san)).To(Succeed())
+			}
+			Expect(game.Opening().String()).To(Equal("C45 Scotch Game"))
+		})
+	})
+
+	Describe("::Undo", func() {
+		When("no moves have been played", func() {
+			It("returns an error", func() {
+				Expect(game.Undo()).ToNot(Succeed())
+			})
+		})
+		When("a position was repeated", func() {
+			BeforeEach(func() {
+				for _, san := range []string{"Nf3", "Nf6", "Ng1", "Ng8"} {
+					Expect(game.MoveSAN(san)).To(Succeed())
+				}
+				Expect(game.CurrentBoard().RepetitionsByKey[GetInitBoard().ZobristKey()]).To(Equal(uint8(2)))
+			})
+			It("restores the repetition counts of the previous position", func() {
+				Expect(game.Undo()).To(Succeed())
+				Expect(game.CurrentBoard().RepetitionsByKey[GetInitBoard().ZobristKey()]).To(Equal(uint8(1)))
+				Expect(game.Moves()).To(HaveLen(3))
+				Expect(game.Positions()).To(HaveLen(4))
+			})
+			It("counts the repetition again when the move is replayed", func() {
+				Expect(game.Undo()).To(Succeed())
+				Expect(game.MoveSAN("Ng8")).To(Succeed())
+				Expect(game.CurrentBoard().RepetitionsByKey[GetInitBoard().ZobristKey()]).To(Equal(uint8(2)))
+			})
+		})
+	})
+
+	Describe("::Redo", func() {
+		BeforeEach(func() {
+			Expect(game.MoveSAN("e4")).To(Succeed())
+			Expect(game.MoveSAN("e5")).To(Succeed())
+			Expect(game.Undo()).To(Succeed())
+			Expect(game.Undo()).To(Succeed())
+		})
+		It("replays undone moves in order", func() {
+			Expect(game.Redo()).To(Succeed())
+			Expect(game.Redo()).To(Succeed())
+			Expect(game.Redo()).ToNot(Succeed())
+			Expect(game.CurrentBoard().ToMiniFEN()).To(Equal("rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6"))
+		})
+		It("keeps the undone moves when the next undone move is played again", func() {
+			Expect(game.MoveSAN("e4")).To(Succeed())
+			Expect(game.CanRedo()).To(BeTrue())
+			Expect(game.Redo()).To(Succeed())
+			Expect(game.Moves()).To(HaveLen(2))
+		})
+		It("discards undone moves once a different move is played", func() {
+			Expect(game.MoveSAN("d4")).To(Succeed())
+			Expect(game.CanRedo()).To(BeFalse())
+			Expect(game.Redo()).ToNot(Succeed())
+			Expect(game.Moves()).To(HaveLen(1))
+		})
+	})
+
+	Describe("::Outcome", func() {
+		It("follows the board while the game is played on the board", func() {
+			Expect(game.Outcome()).To(Equal(NewGameOutcome(GAME_RESULT_IN_PROGRESS, GAME_RESULT_REASON_NONE)))
+			for _, san := range []string{"f3", "e5", "g4", "Qh4"} {
+				Expect(game.MoveSAN(san)).To(Succeed())
+			}
+			Expect(game.Outcome()).To(Equal(NewGameOutcome(GAME_RESULT_BLACK_WINS, GAME_RESULT_REASON_CHECKMATE)))
+		})
+		It("serializes to JSON", func() {
+			Expect(game.Resign(true)).To(Succeed())
+			outcomeJson, err := json.Marshal(game.Outcome())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(outcomeJson)).To(Equal(`{"result":"black_wins","reason":"resignation"}`))
+		})
+	})
+
+	Describe("::Resign", func() {
+		It("ends the game as a win for the opponent", func() {
+			Expect(game.Resign(false)).To(Succeed())
+			Expect(game.Outcome()).To(Equal(NewGameOutcome(GAME_RESULT_WHITE_WINS, GAME_RESULT_REASON_RESIGNATION)))
+			Expect(game.IsOver()).To(BeTrue())
+			Expect(game.MoveSAN("e4")).ToNot(Succeed())
+			Expect(game.Undo()).ToNot(Succeed())
+		})
+	})
+
+	Describe("::AcceptDraw", func() {
+		When("the opponent offered a draw", func() {
+			It("ends the game as a draw by agreement", func() {
+				Expect(game.OfferDraw(true)).To(Succeed())
+				Expect(game.AcceptDraw(false)).To(Succeed())
+				Expect(game.Outcome()).To(Equal(NewGameOutcome(GAME_RESULT_DRAW, GAME_RESULT_REASON_DRAW_AGREEMENT)))
+			})
+		})
+		When("the player accepting made the offer", func() {
+			It("returns an error", func() {
+				Expect(game.OfferDraw(true)).To(Succeed())
+				Expect(game.AcceptDraw(true)).ToNot(Succeed())
+				Expect(game.IsOver()).To(BeFalse())
+			})
+		})
+		When("the opponent moved after the offer", func() {
+			It("returns an error", func() {
+				Expect(game.MoveSAN("e4")).To(Succeed())
+				Expect(game.OfferDraw(true)).To(Succeed())
+				Expect(game.MoveSAN("e5")).To(Succeed())
+				Expect(game.AcceptDraw(false)).ToNot(Succeed())
+			})
+		})
+		When("the offer was declined", func() {
+			It("returns an error", func() {
+				Expect(game.OfferDraw(false)).To(Succeed())
+				Expect(game.DeclineDraw(true)).To(Succeed())
+				Expect(game.AcceptDraw(true)).ToNot(Succeed())
+			})
+		})
+	})
+
+	Describe("::ClaimDraw", func() {
+		BeforeEach(func() {
+			for _, san := range []string{"Nf3", "Nf6", "Ng1", "Ng8", "Nf3", "Nf6", "Ng1"} {
+				Expect(game.MoveSAN(san)).To(Succeed())
+			}
+		})
+		When("the position has not yet occurred three times", func() {
+			It("returns an error", func() {
+				Expect(game.ClaimDraw()).ToNot(Succeed())
+				Expect(game.IsOver()).To(BeFalse())
+			})
+		})
+		When("the position has occurred three times", func() {
+			It("ends the game as a draw by threefold repetition", func() {
+				Expect(game.MoveSAN("Ng8")).To(Succeed())
+				Expect(game.IsOver()).To(BeFalse())
+				Expect(game.ClaimDraw()).To(Succeed())
+				Expect(game.Outcome()).To(Equal(NewGameOutcome(GAME_RESULT_DRAW, GAME_RESULT_REASON_THREEFOLD_REPETITION)))
+			})
+		})
+	})
+
+	Describe("::ClaimDrawWithMove", func() {
+		BeforeEach(func() {
+			for _, san := range []string{"Nf3", "Nf6", "Ng1", "Ng8", "Nf3", "Nf6", "Ng1"} {
+				Expect(game.MoveSAN(san)).To(Succeed())
+			}
+		})
+		When("the move repeats the position a third time", func() {
+			It("plays the move and ends the game as a draw", func() {
+				move := &Move{Piece: BLACK_KNIGHT, StartSquare: &Square{6, 6}, EndSquare: &Square{8, 7}}
+				Expect(game.ClaimDrawWithMove(move)).To(Succeed())
+				Expect(game.Moves()).To(HaveLen(8))
+				Expect(game.Outcome()).To(Equal(NewGameOutcome(GAME_RESULT_DRAW, GAME_RESULT_REASON_THREEFOLD_REPETITION)))
+			})
+		})
+		When("the move does not allow a claim", func() {
+			It("does not play the move", func() {
+				move := &Move{Piece: BLACK_PAWN, StartSquare: &Square{7, 5}, EndSquare: &Square{5, 5}}
+				Expect(game.ClaimDrawWithMove(move)).ToNot(Succeed())
+				Expect(game.Moves()).To(HaveLen(7))
+			})
+		})
+	})
+
+	Describe("::Timeout", func() {
+		It("ends the game as a win for the opponent", func() {
+			Expect(game.Timeout(true)).To(Succeed())
+			Expect(game.Outcome()).To(Equal(NewGameOutcome(GAME_RESULT_BLACK_WINS, GAME_RESULT_REASON_TIMEOUT)))
+		})
+		When("the opponent cannot possibly checkmate", func() {
+			It("ends the game as a draw", func() {
+				board, _ := BoardFromFEN("8/8/8/8/7K/8/8/k5R1 w - - 0 1")
+				game = NewGameFromBoard(board)
+				Expect(game.Timeout(true)).To(Succeed())
+				Expect(game.Outcome()).To(Equal(NewGameOutcome(GAME_RESULT_DRAW, GAME_RESULT_REASON_TIMEOUT_VS_INSUFFICIENT_MATERIAL)))
+			})
+		})
+		When("the opponent could only mate with help", func() {
+			It("ends the game as a win for the opponent", func() {
+				board, _ := BoardFromFEN("8/8/8/8/4N2K/8/8/k5r1 w - - 0 1")
+				game = NewGameFromBoard(board)
+				Expect(game.Timeout(false)).To(Succeed())
+				Expect(game.Outcome()).To(Equal(NewGameOutcome(GAME_RESULT_WHITE_WINS, GAME_RESULT_REASON_TIMEOUT)))
+			})
+		})
+	})
+
+	Describe("::Abandon", func() {
+		It("ends the game as a win for the opponent", func() {
+			Expect(game.Abandon(true)).To(Succeed())
+			Expect(game.Outcome()).To(Equal(NewGameOutcome(GAME_RESULT_BLACK_WINS, GAME_RESULT_REASON_ABANDONMENT)))
+		})
+	})
+
+	Describe("::ToPGN", func() {
+		When("the game ended by timeout", func() {
+			It("writes the result and termination tags", func() {
+				Expect(game.MoveSAN("e4")).To(Succeed())
+				Expect(game.Timeout(false)).To(Succeed())
+				pgn, err := game.ToPGN([]*PGNTag{{"Event", "Blitz"}})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pgn).To(ContainSubstring("[Result \"1-0\"]\n"))
+				Expect(pgn).To(ContainSubstring("[Termination \"time forfeit\"]\n"))
+				Expect(pgn).To(HaveSuffix("\n1. e4 1-0\n"))
+			})
+		})
+		When("the game was aborted", func() {
+			It("writes an unknown result", func() {
+				Expect(game.Abort()).To(Succeed())
+				pgn, err := game.ToPGN([]*PGNTag{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pgn).To(ContainSubstring("[Result \"*\"]\n"))
+				Expect(pgn).To(ContainSubstring("[Termination \"abandoned\"]\n"))
+			})
+		})
+	})
+})
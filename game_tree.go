@@ -0,0 +1,321 @@
+package chess
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PGN_NAG_BY_SUFFIX maps the traditional move suffix annotations onto their NAG equivalents
+var PGN_NAG_BY_SUFFIX = map[string]uint8{
+	"!":  1,
+	"?":  2,
+	"!!": 3,
+	"??": 4,
+	"!?": 5,
+	"?!": 6,
+}
+
+type PGNComment struct {
+	Text          string `json:"text"`
+	IsLineComment bool   `json:"isLineComment"`
+}
+
+// GameNode is a single position in a GameTree. Variations[0] continues the line the node belongs to; any
+// further entries are alternatives to that continuation.
+type GameNode struct {
+	Board *Board `json:"board"`
+	Move  *Move  `json:"move"`
+	// PreComments are written before the move, which only happens at the start of a variation or right after
+	// a variation closes
+	PreComments []*PGNComment `json:"preComments"`
+	Comments    []*PGNComment `json:"comments"`
+	NAGs        []uint8       `json:"nags"`
+	Variations  []*GameNode   `json:"variations"`
+	Parent      *GameNode     `json:"-"`
+}
+
+func newGameNode(board *Board, move *Move, parent *GameNode) *GameNode {
+	return &GameNode{
+		Board:       board,
+		Move:        move,
+		PreComments: make([]*PGNComment, 0),
+		Comments:    make([]*PGNComment, 0),
+		NAGs:        make([]uint8, 0),
+		Variations:  make([]*GameNode, 0),
+		Parent:      parent,
+	}
+}
+
+// AddVariation returns the child node reached by the move, creating it if no child already plays that move.
+// The first child added becomes the continuation of the current line.
+func (node *GameNode) AddVariation(move *Move) (*GameNode, error) {
+	for _, child := range node.Variations {
+		if child.Move.Equal(move) {
+			return child, nil
+		}
+	}
+	if !IsLegalMove(node.Board, move) {
+		return nil, fmt.Errorf("move %s is not legal on %s", move.ToLongAlgebraic(), node.Board)
+	}
+	child := newGameNode(GetBoardFromMove(node.Board, move), move, node)
+	node.Variations = append(node.Variations, child)
+	return child, nil
+}
+
+// Ply counts the moves played to reach the node, the root being ply 0
+func (node *GameNode) Ply() int {
+	ply := 0
+	for curr := node; curr.Parent != nil; curr = curr.Parent {
+		ply++
+	}
+	return ply
+}
+
+// MainLine follows the first variation of every node, starting with the node itself
+func (node *GameNode) MainLine() []*GameNode {
+	line := []*GameNode{node}
+	for curr := node; len(curr.Variations) > 0; {
+		curr = curr.Variations[0]
+		line = append(line, curr)
+	}
+	return line
+}
+
+type GameTree struct {
+	Tags   []*PGNTag `json:"tags"`
+	Root   *GameNode `json:"root"`
+	Result string    `json:"result"`
+}
+
+func NewGameTree(startBoard *Board) *GameTree {
+	return &GameTree{
+		Tags:   make([]*PGNTag, 0),
+		Root:   newGameNode(startBoard, nil, nil),
+		Result: "*",
+	}
+}
+
+func (tree *GameTree) GetTag(name string) (string, bool) {
+	for _, tag := range tree.Tags {
+		if tag.Name == name {
+			return tag.Value, true
+		}
+	}
+	return "", false
+}
+
+// ToPGNGame flattens the main line of the tree, dropping comments, NAGs and variations
+func (tree *GameTree) ToPGNGame() *PGNGame {
+	game := &PGNGame{
+		Tags:   tree.Tags,
+		Moves:  make([]*Move, 0),
+		Boards: make([]*Board, 0),
+		Result: tree.Result,
+	}
+	for _, node := range tree.Root.MainLine() {
+		if node.Move != nil {
+			game.Moves = append(game.Moves, node.Move)
+		}
+		game.Boards = append(game.Boards, node.Board)
+	}
+	return game
+}
+
+// ToPGN writes the tree in PGN export format, see PGNFromMoves for how tags and the result are chosen.
+// Suffix annotations read from the import are written as their NAG equivalents.
+func (tree *GameTree) ToPGN() (string, error) {
+	movetext := &pgnLineWriter{}
+	writePGNComments(movetext, tree.Root.Comments)
+	if len(tree.Root.Variations) > 0 {
+		writePGNLine(movetext, tree.Root.Variations[0], true)
+	}
+
+	mainLine := tree.Root.MainLine()
+	result := pgnResultFromBoardResult(mainLine[len(mainLine)-1].Board.Result)
+	if result == "*" {
+		if isPGNResult(tree.Result) {
+			result = tree.Result
+		} else if tagResult, ok := tree.GetTag("Result"); ok && isPGNResult(tagResult) {
+			result = tagResult
+		}
+	}
+	movetext.writeToken(result)
+	return writePGN(tree.Tags, tree.Root.Board, result, movetext), nil
+}
+
+// writePGNLine writes the node, its alternatives and its continuation. Black moves only get a move number when
+// they start a line or follow a comment or variation.
+func writePGNLine(movetext *pgnLineWriter, node *GameNode, isMoveNumberNeeded bool) {
+	for {
+		writePGNComments(movetext, node.PreComments)
+		isMoveNumberNeeded = isMoveNumberNeeded || len(node.PreComments) > 0
+		prevBoard := node.Parent.Board
+		san := node.Move.ToAlgebraic(prevBoard)
+		if prevBoard.IsWhiteTurn {
+			movetext.writeToken(fmt.Sprintf("%d. %s", prevBoard.FullMoveCount, san))
+		} else if isMoveNumberNeeded {
+			movetext.writeToken(fmt.Sprintf("%d... %s", prevBoard.FullMoveCount, san))
+		} else {
+			movetext.writeToken(san)
+		}
+		for _, nag := range node.NAGs {
+			movetext.writeToken(fmt.Sprintf("$%d", nag))
+		}
+		writePGNComments(movetext, node.Comments)
+		isMoveNumberNeeded = len(node.Comments) > 0
+
+		siblings := node.Parent.Variations
+		if siblings[0] == node {
+			for _, sibling := range siblings[1:] {
+				movetext.openParen()
+				writePGNLine(movetext, sibling, true)
+				movetext.closeParen()
+				isMoveNumberNeeded = true
+			}
+		}
+		if len(node.Variations) == 0 {
+			return
+		}
+		node = node.Variations[0]
+	}
+}
+
+func writePGNComments(movetext *pgnLineWriter, comments []*PGNComment) {
+	for _, comment := range comments {
+		if comment.IsLineComment {
+			movetext.writeLineComment(comment.Text)
+			continue
+		}
+		words := strings.Fields(comment.Text)
+		if len(words) == 0 {
+			movetext.writeToken("{}")
+			continue
+		}
+		words[0] = "{" + words[0]
+		words[len(words)-1] += "}"
+		for _, word := range words {
+			movetext.writeToken(word)
+		}
+	}
+}
+
+// ParsePGNTree reads a single game in PGN import format, keeping comments, NAGs and recursive annotation
+// variations. Moves in variations are validated the same way as moves in the main line.
+func ParsePGNTree(pgn string) (*GameTree, error) {
+	tokens, tokenizeErr := tokenizePGN(pgn)
+	if tokenizeErr != nil {
+		return nil, tokenizeErr
+	}
+	tags, tagsEnd, tagsErr := parsePGNTags(tokens)
+	if tagsErr != nil {
+		return nil, tagsErr
+	}
+	startBoard, startBoardErr := startBoardFromPGNTags(tags)
+	if startBoardErr != nil {
+		return nil, startBoardErr
+	}
+	tree := NewGameTree(startBoard)
+	tree.Tags = tags
+	tree.Result = ""
+
+	// each entry is the node a variation was branched from, restored once the variation closes
+	variationStack := make([]*GameNode, 0)
+	node := tree.Root
+	// comments are held back at the start of a variation and after one closes, since they describe the next move
+	pendingComments := make([]*PGNComment, 0)
+	isHoldingComments := false
+	releaseComments := func() {
+		node.Comments = append(node.Comments, pendingComments...)
+		pendingComments = make([]*PGNComment, 0)
+		isHoldingComments = false
+	}
+
+	for _, token := range tokens[tagsEnd:] {
+		if tree.Result != "" {
+			break
+		}
+		switch token.tokenType {
+		case pgnTokenComment, pgnTokenLineComment:
+			comment := &PGNComment{token.value, token.tokenType == pgnTokenLineComment}
+			if isHoldingComments {
+				pendingComments = append(pendingComments, comment)
+			} else {
+				node.Comments = append(node.Comments, comment)
+			}
+		case pgnTokenNAG, pgnTokenSuffix:
+			if node.Move == nil {
+				return nil, fmt.Errorf("invalid PGN: annotation %s before the first move", token.value)
+			}
+			nag, nagErr := nagFromPGNToken(token)
+			if nagErr != nil {
+				return nil, fmt.Errorf("invalid PGN: could not read annotation after ply %d: %w", node.Ply(), nagErr)
+			}
+			node.NAGs = append(node.NAGs, nag)
+		case pgnTokenLeftParen:
+			if node.Parent == nil {
+				return nil, fmt.Errorf("invalid PGN: variation before the first move")
+			}
+			releaseComments()
+			variationStack = append(variationStack, node)
+			node = node.Parent
+			isHoldingComments = true
+		case pgnTokenRightParen:
+			if len(variationStack) == 0 {
+				return nil, fmt.Errorf("invalid PGN: unmatched ')' after ply %d", node.Ply())
+			}
+			releaseComments()
+			node = variationStack[len(variationStack)-1]
+			variationStack = variationStack[:len(variationStack)-1]
+			isHoldingComments = true
+		case pgnTokenAsterisk:
+			tree.Result = token.value
+		case pgnTokenSymbol:
+			if isPGNResult(token.value) {
+				tree.Result = token.value
+				break
+			}
+			if isPGNMoveNumber(token.value) {
+				break
+			}
+			ply := node.Ply() + 1
+			move, moveErr := MoveFromAlgebraic(sanFromPGNToken(token.value), node.Board)
+			if moveErr != nil {
+				return nil, fmt.Errorf("invalid PGN: could not read move %s at ply %d: %w", token.value, ply, moveErr)
+			}
+			child := newGameNode(GetBoardFromMove(node.Board, move), move, node)
+			child.PreComments = pendingComments
+			node.Variations = append(node.Variations, child)
+			node = child
+			pendingComments = make([]*PGNComment, 0)
+			isHoldingComments = false
+		}
+	}
+	if len(variationStack) > 0 {
+		return nil, fmt.Errorf("invalid PGN: unterminated variation after ply %d", node.Ply())
+	}
+	releaseComments()
+	if tree.Result == "" {
+		if result, ok := tree.GetTag("Result"); ok {
+			tree.Result = result
+		} else {
+			tree.Result = "*"
+		}
+	}
+	return tree, nil
+}
+
+func nagFromPGNToken(token *pgnToken) (uint8, error) {
+	if token.tokenType == pgnTokenSuffix {
+		nag, ok := PGN_NAG_BY_SUFFIX[token.value]
+		if !ok {
+			return 0, fmt.Errorf("unknown suffix annotation %s", token.value)
+		}
+		return nag, nil
+	}
+	nag, err := strconv.Atoi(token.value)
+	if err != nil || nag > 255 {
+		return 0, fmt.Errorf("NAG $%s outside expected range [0, 255]", token.value)
+	}
+	return uint8(nag), nil
+}
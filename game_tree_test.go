@@ -0,0 +1,120 @@
+package chess_test
+
+import (
+	. "github.com/CameronHonis/chess"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const ANNOTATED_GAME_PGN = `[Event "Casual Game"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "?"]
+[Black "?"]
+[Result "*"]
+
+{Game comment} 1. e4 $1 {the most popular first move} (1. d4 d5 (1... Nf6 2. c4
+{the Indian complex}) 2. c4) (1. Nf3) 1... e5 2. Nf3 ;a line comment
+2... Nc6 3. Bb5 a6 $6 *
+`
+
+var _ = Describe("GameTree", func() {
+	Describe("#ParsePGNTree", func() {
+		var tree *GameTree
+		BeforeEach(func() {
+			var err error
+			tree, err = ParsePGNTree(ANNOTATED_GAME_PGN)
+			Expect(err).ToNot(HaveOccurred())
+		})
+		It("keeps the comment before the first move on the root", func() {
+			Expect(tree.Root.Comments).To(Equal([]*PGNComment{{"Game comment", false}}))
+		})
+		It("keeps NAGs and comments on the move they follow", func() {
+			e4 := tree.Root.Variations[0]
+			Expect(e4.NAGs).To(Equal([]uint8{1}))
+			Expect(e4.Comments).To(Equal([]*PGNComment{{"the most popular first move", false}}))
+		})
+		It("keeps line comments", func() {
+			nf3 := tree.Root.MainLine()[3]
+			Expect(nf3.Comments).To(Equal([]*PGNComment{{"a line comment", true}}))
+		})
+		It("keeps each variation as an alternative to the main line move", func() {
+			Expect(tree.Root.Variations).To(HaveLen(3))
+			d4 := tree.Root.Variations[1]
+			Expect(d4.Move.EndSquare).To(Equal(&Square{4, 4}))
+			Expect(d4.Variations).To(HaveLen(2))
+			nf6 := d4.Variations[1]
+			Expect(nf6.Move.Piece).To(Equal(BLACK_KNIGHT))
+			Expect(nf6.Variations[0].Comments).To(Equal([]*PGNComment{{"the Indian complex", false}}))
+		})
+		It("computes the board of every node", func() {
+			nf6 := tree.Root.Variations[1].Variations[1]
+			Expect(nf6.Board.ToMiniFEN()).To(Equal("rnbqkb1r/pppppppp/5n2/8/3P4/8/PPP1PPPP/RNBQKBNR w KQkq -"))
+			Expect(nf6.Ply()).To(Equal(2))
+		})
+		It("converts suffix annotations to NAGs", func() {
+			tree, err := ParsePGNTree("1. e4! e5?! *")
+			Expect(err).ToNot(HaveOccurred())
+			mainLine := tree.Root.MainLine()
+			Expect(mainLine[1].NAGs).To(Equal([]uint8{1}))
+			Expect(mainLine[2].NAGs).To(Equal([]uint8{6}))
+		})
+		When("a variation holds an illegal move", func() {
+			It("returns an error naming the ply and token", func() {
+				_, err := ParsePGNTree("1. e4 (1. e5) e5 *")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("e5 at ply 1"))
+			})
+		})
+		When("a variation is never closed", func() {
+			It("returns an error", func() {
+				_, err := ParsePGNTree("1. e4 (1. d4 e5 *")
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("::ToPGN", func() {
+		It("round trips an annotated game", func() {
+			tree, err := ParsePGNTree(ANNOTATED_GAME_PGN)
+			Expect(err).ToNot(HaveOccurred())
+			pgn, err := tree.ToPGN()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pgn).To(Equal(ANNOTATED_GAME_PGN))
+		})
+		It("is stable when an imported game is saved again", func() {
+			tree, _ := ParsePGNTree("1.e4   {spread   out\ncomment}e5!(1...c5)  2.Nf3 *")
+			pgn, err := tree.ToPGN()
+			Expect(err).ToNot(HaveOccurred())
+			reloadedTree, err := ParsePGNTree(pgn)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reloadedTree.ToPGN()).To(Equal(pgn))
+		})
+		It("numbers black moves that follow a comment", func() {
+			tree, _ := ParsePGNTree("1. e4 {comment} e5 *")
+			pgn, err := tree.ToPGN()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pgn).To(HaveSuffix("\n1. e4 {comment} 1... e5 *\n"))
+		})
+	})
+
+	Describe("::AddVariation", func() {
+		It("reuses the child that already plays the move", func() {
+			tree := NewGameTree(GetInitBoard())
+			move, _ := MoveFromAlgebraic("e4", tree.Root.Board)
+			first, err := tree.Root.AddVariation(move)
+			Expect(err).ToNot(HaveOccurred())
+			second, err := tree.Root.AddVariation(move)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(second).To(BeIdenticalTo(first))
+			Expect(tree.Root.Variations).To(HaveLen(1))
+		})
+		It("rejects illegal moves", func() {
+			tree := NewGameTree(GetInitBoard())
+			move := &Move{WHITE_PAWN, &Square{2, 5}, &Square{5, 5}, EMPTY, make([]*Square, 0), EMPTY}
+			_, err := tree.Root.AddVariation(move)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
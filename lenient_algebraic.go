@@ -0,0 +1,251 @@
+package chess
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SANNormalization names a repair MoveFromLenientAlgebraic made to read a move that isn't strict SAN
+type SANNormalization string
+
+const (
+	// SAN_NORMALIZATION_FIGURINE replaced Unicode chess piece glyphs with piece letters, e.g. ♘f3
+	SAN_NORMALIZATION_FIGURINE SANNormalization = "figurine"
+	// SAN_NORMALIZATION_ZERO_CASTLING read castling written with zeros or lowercase letters, e.g. 0-0
+	SAN_NORMALIZATION_ZERO_CASTLING SANNormalization = "zero_castling"
+	// SAN_NORMALIZATION_CAPTURE_MARK added a missing x to a capture or dropped an x from a move that captures
+	// nothing, e.g. ed5
+	SAN_NORMALIZATION_CAPTURE_MARK SANNormalization = "capture_mark"
+	// SAN_NORMALIZATION_PROMOTION_EQUALS added the = missing before a promotion piece, e.g. e8Q
+	SAN_NORMALIZATION_PROMOTION_EQUALS SANNormalization = "promotion_equals"
+	// SAN_NORMALIZATION_EN_PASSANT_SUFFIX dropped an en passant suffix, e.g. exd6e.p.
+	SAN_NORMALIZATION_EN_PASSANT_SUFFIX SANNormalization = "en_passant_suffix"
+	// SAN_NORMALIZATION_PAWN_CAPTURE_SHORTHAND read a pawn capture given by files alone, e.g. ed or exd
+	SAN_NORMALIZATION_PAWN_CAPTURE_SHORTHAND SANNormalization = "pawn_capture_shorthand"
+	// SAN_NORMALIZATION_LONG_ALGEBRAIC read a move given by its origin square, e.g. Ng1-f3 or e2e4
+	SAN_NORMALIZATION_LONG_ALGEBRAIC SANNormalization = "long_algebraic"
+	// SAN_NORMALIZATION_CHECK_SUFFIX corrected a check or mate suffix that doesn't fit the move, e.g. Kxe2#
+	SAN_NORMALIZATION_CHECK_SUFFIX SANNormalization = "check_suffix"
+)
+
+var pieceLetterByFigurine = map[rune]string{
+	'♔': "K", '♕': "Q", '♖': "R", '♗': "B", '♘': "N", '♙': "",
+	'♚': "K", '♛': "Q", '♜': "R", '♝': "B", '♞': "N", '♟': "",
+}
+
+// MoveFromLenientAlgebraic reads a move the way a player or OCR might write it, accepting the variants of SAN
+// listed by the SANNormalization constants. It returns the normalizations it applied, which are empty for
+// strict SAN. Errors are *MoveError values, like those of MoveFromAlgebraic.
+func MoveFromLenientAlgebraic(algMove string, priorBoard *Board) (*Move, []SANNormalization, error) {
+	normalizations := make([]SANNormalization, 0)
+	normalize := func(normalization SANNormalization) {
+		for _, applied := range normalizations {
+			if applied == normalization {
+				return
+			}
+		}
+		normalizations = append(normalizations, normalization)
+	}
+
+	notation := strings.TrimSpace(algMove)
+	var figurineBuilder strings.Builder
+	for _, notationRune := range notation {
+		if pieceLetter, ok := pieceLetterByFigurine[notationRune]; ok {
+			figurineBuilder.WriteString(pieceLetter)
+			normalize(SAN_NORMALIZATION_FIGURINE)
+		} else {
+			figurineBuilder.WriteRune(notationRune)
+		}
+	}
+	notation = strings.TrimRight(figurineBuilder.String(), "!?")
+
+	checkSuffix := ""
+	if strings.HasSuffix(notation, "#") {
+		checkSuffix = "#"
+	} else if strings.HasSuffix(notation, "+") {
+		checkSuffix = "+"
+	}
+	notation = strings.TrimRight(notation, "+#")
+
+	for _, enPassantSuffix := range []string{"e.p.", "ep"} {
+		if strings.HasSuffix(strings.ToLower(notation), enPassantSuffix) {
+			notation = strings.TrimSpace(notation[:len(notation)-len(enPassantSuffix)])
+			normalize(SAN_NORMALIZATION_EN_PASSANT_SUFFIX)
+			break
+		}
+	}
+	notation = strings.ReplaceAll(notation, " ", "")
+
+	var move *Move
+	if castleNotation, ok := readLenientCastling(notation); ok {
+		if castleNotation != notation {
+			normalize(SAN_NORMALIZATION_ZERO_CASTLING)
+		}
+		castleMove, castleErr := castleMoveFromAlgebraic(castleNotation, priorBoard)
+		if castleErr != nil {
+			return nil, nil, withMoveNotation(castleErr, algMove)
+		}
+		move = castleMove
+	} else {
+		pieceMove, moveErr := lenientPieceMove(algMove, notation, priorBoard, normalize)
+		if moveErr != nil {
+			return nil, nil, moveErr
+		}
+		move = pieceMove
+	}
+
+	expCheckSuffix := ""
+	if len(move.KingCheckingSquares) > 0 {
+		expCheckSuffix = "+"
+		if GetBoardFromMove(priorBoard, move).IsCheckmate() {
+			expCheckSuffix = "#"
+		}
+	}
+	if checkSuffix != "" && checkSuffix != expCheckSuffix {
+		normalize(SAN_NORMALIZATION_CHECK_SUFFIX)
+	}
+	return move, normalizations, nil
+}
+
+// readLenientCastling returns the strict SAN of castling written with letter O, digit 0 or lowercase o, with or
+// without hyphens
+func readLenientCastling(notation string) (string, bool) {
+	castleNotation := strings.NewReplacer("0", "O", "o", "O", "-", "").Replace(notation)
+	if castleNotation == "OO" {
+		return "O-O", true
+	} else if castleNotation == "OOO" {
+		return "O-O-O", true
+	}
+	return "", false
+}
+
+// lenientPieceMove matches a non-castling move, written as an optional piece letter, an optional origin file
+// and rank, an optional x or hyphen, a land file with an optional rank and an optional promotion piece,
+// against the legal moves of the board
+func lenientPieceMove(algMove string, notation string, priorBoard *Board, normalize func(SANNormalization)) (*Move, error) {
+	malformedErr := func(reason string) error {
+		return &MoveError{Notation: algMove, Err: fmt.Errorf("%w, %s", ErrMalformedNotation, reason)}
+	}
+	if notation == "" {
+		return nil, malformedErr("empty move")
+	}
+
+	isPawn := true
+	pieceLetter := notation[0]
+	if strings.IndexByte("KQRBN", pieceLetter) >= 0 {
+		isPawn = false
+		notation = notation[1:]
+	}
+
+	var upgradeLetter byte
+	if idx := strings.IndexByte(notation, '='); idx >= 0 {
+		if idx != len(notation)-2 || strings.IndexByte("QRBN", notation[idx+1]) < 0 {
+			return nil, malformedErr("promotion must be one of Q, R, B or N")
+		}
+		upgradeLetter = notation[idx+1]
+		notation = notation[:idx]
+	} else if len(notation) >= 2 && strings.IndexByte("QRBNqrn", notation[len(notation)-1]) >= 0 &&
+		isRankChar(notation[len(notation)-2]) {
+		upgradeLetter = strings.ToUpper(notation[len(notation)-1:])[0]
+		notation = notation[:len(notation)-1]
+		normalize(SAN_NORMALIZATION_PROMOTION_EQUALS)
+	}
+
+	hasCaptureMark := false
+	hasHyphen := false
+	if idx := strings.IndexAny(notation, "x-:"); idx >= 0 {
+		hasCaptureMark = notation[idx] != '-'
+		hasHyphen = notation[idx] == '-'
+		notation = notation[:idx] + notation[idx+1:]
+	}
+
+	var landFile, landRank, originFile, originRank uint8
+	if len(notation) > 0 && isRankChar(notation[len(notation)-1]) {
+		landRank = notation[len(notation)-1] - '0'
+		notation = notation[:len(notation)-1]
+	}
+	if len(notation) == 0 || !isFileChar(notation[len(notation)-1]) {
+		return nil, malformedErr("missing land square")
+	}
+	landFile = notation[len(notation)-1] - 'a' + 1
+	notation = notation[:len(notation)-1]
+	if len(notation) > 0 && isRankChar(notation[len(notation)-1]) {
+		originRank = notation[len(notation)-1] - '0'
+		notation = notation[:len(notation)-1]
+	}
+	if len(notation) > 0 && isFileChar(notation[len(notation)-1]) {
+		originFile = notation[len(notation)-1] - 'a' + 1
+		notation = notation[:len(notation)-1]
+	}
+	if len(notation) > 0 {
+		return nil, malformedErr(fmt.Sprintf("unexpected characters %s", notation))
+	}
+	if landRank == 0 {
+		if !isPawn || originFile == 0 || originFile == landFile {
+			return nil, malformedErr("missing land rank")
+		}
+		normalize(SAN_NORMALIZATION_PAWN_CAPTURE_SHORTHAND)
+	}
+	if hasHyphen || (originFile != 0 && originRank != 0) {
+		normalize(SAN_NORMALIZATION_LONG_ALGEBRAIC)
+	}
+
+	piece := pieceFromLetter(pieceLetter, isPawn, priorBoard.IsWhiteTurn)
+	upgradePiece := EMPTY
+	if upgradeLetter != 0 {
+		upgradePiece = pieceFromLetter(upgradeLetter, false, priorBoard.IsWhiteTurn)
+	}
+	legalMoves, movesErr := GetLegalMoves(priorBoard)
+	if movesErr != nil {
+		return nil, withMoveNotation(movesErr, algMove)
+	}
+	pieceMoves := make([]*Move, 0)
+	matchingMoves := make([]*Move, 0)
+	for _, legalMove := range legalMoves {
+		if legalMove.Piece != piece || legalMove.IsCastlesOn(priorBoard) {
+			continue
+		}
+		pieceMoves = append(pieceMoves, legalMove)
+		if legalMove.EndSquare.File != landFile || (landRank != 0 && legalMove.EndSquare.Rank != landRank) {
+			continue
+		}
+		if (originFile != 0 && legalMove.StartSquare.File != originFile) ||
+			(originRank != 0 && legalMove.StartSquare.Rank != originRank) {
+			continue
+		}
+		if (landRank == 0 && legalMove.CapturedPiece == EMPTY) || legalMove.PawnUpgradedTo != upgradePiece {
+			continue
+		}
+		matchingMoves = append(matchingMoves, legalMove)
+	}
+	if len(matchingMoves) > 1 {
+		return nil, &MoveError{Notation: algMove, Piece: piece, Candidates: matchingMoves, Err: ErrAmbiguousMove}
+	}
+	if len(matchingMoves) == 0 {
+		return nil, &MoveError{Notation: algMove, Piece: piece, Candidates: pieceMoves, Err: ErrIllegalMove}
+	}
+	move := matchingMoves[0]
+	if hasCaptureMark != (move.CapturedPiece != EMPTY) && landRank != 0 {
+		normalize(SAN_NORMALIZATION_CAPTURE_MARK)
+	}
+	return move, nil
+}
+
+func pieceFromLetter(letter byte, isPawn bool, isWhite bool) Piece {
+	piece := WHITE_PAWN
+	if !isPawn {
+		piece = map[byte]Piece{'N': WHITE_KNIGHT, 'B': WHITE_BISHOP, 'R': WHITE_ROOK, 'Q': WHITE_QUEEN, 'K': WHITE_KING}[letter]
+	}
+	if !isWhite {
+		return blackPieceOf(piece)
+	}
+	return piece
+}
+
+func isFileChar(c byte) bool {
+	return c >= 'a' && c <= 'h'
+}
+
+func isRankChar(c byte) bool {
+	return c >= '1' && c <= '8'
+}
@@ -0,0 +1,62 @@
+package chess_test
+
+import (
+	"errors"
+
+	. "github.com/CameronHonis/chess"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("#MoveFromLenientAlgebraic", func() {
+	DescribeTable("normalizes the move to its strict SAN",
+		func(fen string, algMove string, expSAN string, expNormalizations ...SANNormalization) {
+			board, err := BoardFromFEN(fen)
+			Expect(err).ToNot(HaveOccurred())
+			move, normalizations, err := MoveFromLenientAlgebraic(algMove, board)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(move.ToAlgebraic(board)).To(Equal(expSAN))
+			if len(expNormalizations) == 0 {
+				Expect(normalizations).To(BeEmpty())
+			} else {
+				Expect(normalizations).To(Equal(expNormalizations))
+			}
+		},
+		Entry("strict SAN", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "Nf3", "Nf3"),
+		Entry("promotion without =", "8/4P1k1/8/8/8/8/8/K7 w - - 0 1", "e8Q", "e8=Q",
+			SAN_NORMALIZATION_PROMOTION_EQUALS),
+		Entry("en passant suffix", "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "exd6e.p.", "exd6",
+			SAN_NORMALIZATION_EN_PASSANT_SUFFIX),
+		Entry("zero castling", "4k3/8/8/8/8/8/8/4K2R w K - 0 1", "0-0", "O-O",
+			SAN_NORMALIZATION_ZERO_CASTLING),
+		Entry("check suffix on a quiet move", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "Nf3+", "Nf3",
+			SAN_NORMALIZATION_CHECK_SUFFIX),
+		Entry("mate suffix on a capture that isn't mate", "4k3/8/8/8/8/8/4p3/4K3 w - - 0 1", "Kxe2#", "Kxe2",
+			SAN_NORMALIZATION_CHECK_SUFFIX),
+		Entry("missing capture mark", "4k3/8/8/3p4/4P3/8/8/4K3 w - - 0 1", "ed5", "exd5",
+			SAN_NORMALIZATION_CAPTURE_MARK),
+		Entry("pawn capture shorthand", "4k3/8/8/3p4/4P3/8/8/4K3 w - - 0 1", "exd", "exd5",
+			SAN_NORMALIZATION_PAWN_CAPTURE_SHORTHAND),
+		Entry("hyphenated long algebraic", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "Ng1-f3", "Nf3",
+			SAN_NORMALIZATION_LONG_ALGEBRAIC),
+		Entry("figurine", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "♘f3", "Nf3",
+			SAN_NORMALIZATION_FIGURINE),
+		Entry("several variants at once", "8/4P1k1/8/8/8/8/8/K7 w - - 0 1", "e7-e8♕+", "e8=Q",
+			SAN_NORMALIZATION_FIGURINE, SAN_NORMALIZATION_PROMOTION_EQUALS, SAN_NORMALIZATION_LONG_ALGEBRAIC,
+			SAN_NORMALIZATION_CHECK_SUFFIX),
+	)
+
+	When("the shorthand matches more than one capture", func() {
+		It("returns an ambiguous move error", func() {
+			board, _ := BoardFromFEN("4k3/8/8/3p4/2P1P3/8/8/4K3 w - - 0 1")
+			_, _, err := MoveFromLenientAlgebraic("d5", board)
+			Expect(errors.Is(err, ErrAmbiguousMove)).To(BeTrue())
+		})
+	})
+	When("the notation cannot be read", func() {
+		It("returns a malformed notation error", func() {
+			_, _, err := MoveFromLenientAlgebraic("Nf3zz", GetInitBoard())
+			Expect(errors.Is(err, ErrMalformedNotation)).To(BeTrue())
+		})
+	})
+})
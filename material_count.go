@@ -76,3 +76,44 @@ func (mcb *MaterialCountBuilder) WithMaterialCount(otherMaterialCount *MaterialC
 func (mcb *MaterialCountBuilder) Build() *MaterialCount {
 	return mcb.materialCount
 }
+
+// HasMatingMaterial reports whether the given side could possibly deliver checkmate by any sequence of legal
+// moves, allowing for the opponent's help. This is what decides a flag fall: a player whose opponent can't
+// possibly mate them is saved by the draw.
+func (mat *MaterialCount) HasMatingMaterial(isWhite bool) bool {
+	pawns, knights, lightBishops, darkBishops, rooks, queens := mat.sideCounts(isWhite)
+	if pawns > 0 || rooks > 0 || queens > 0 {
+		return true
+	}
+	bishops := lightBishops + darkBishops
+	if knights+bishops == 0 {
+		return false
+	}
+	if knights > 1 || (knights > 0 && bishops > 0) || (lightBishops > 0 && darkBishops > 0) {
+		return true
+	}
+
+	oppPawns, oppKnights, oppLightBishops, oppDarkBishops, oppRooks, oppQueens := mat.sideCounts(!isWhite)
+	if knights > 0 {
+		// a lone knight can only mate when the opposing king is boxed in by its own pieces
+		return oppPawns+oppKnights+oppLightBishops+oppDarkBishops+oppRooks+oppQueens > 0
+	}
+	// bishops all on one color can't cover the mating square's neighbours of the other color, unless the
+	// opposing king's own pieces block them
+	if oppPawns > 0 || oppKnights > 0 || oppRooks > 0 || oppQueens > 0 {
+		return true
+	}
+	if lightBishops > 0 {
+		return oppDarkBishops > 0
+	}
+	return oppLightBishops > 0
+}
+
+func (mat *MaterialCount) sideCounts(isWhite bool) (pawns, knights, lightBishops, darkBishops, rooks, queens uint8) {
+	if isWhite {
+		return mat.WhitePawnCount, mat.WhiteKnightCount, mat.WhiteLightBishopCount, mat.WhiteDarkBishopCount,
+			mat.WhiteRookCount, mat.WhiteQueenCount
+	}
+	return mat.BlackPawnCount, mat.BlackKnightCount, mat.BlackLightBishopCount, mat.BlackDarkBishopCount,
+		mat.BlackRookCount, mat.BlackQueenCount
+}
@@ -50,16 +50,36 @@ func (move *Move) DoesAllowEnPassant() bool {
 	return dis == 2 || dis == -2
 }
 
+// IsCastles recognizes castling in standard chess, where the king moves two squares from the e-file. Chess960
+// castling is written as the king moving onto its own rook, which takes the board to recognize, see IsCastlesOn.
 func (move *Move) IsCastles() bool {
 	if !move.Piece.IsKing() {
 		return false
 	}
-	return move.StartSquare.File == 5 && (move.EndSquare.File == 3 || move.EndSquare.File == 7)
+	return move.StartSquare.File == 5 && move.StartSquare.Rank == move.EndSquare.Rank &&
+		(move.EndSquare.File == 3 || move.EndSquare.File == 7)
+}
+
+// IsCastlesOn reports whether the move castles on the board the move is made from
+func (move *Move) IsCastlesOn(board *Board) bool {
+	if !move.Piece.IsKing() {
+		return false
+	}
+	if board.IsChess960 {
+		landPiece := board.GetPieceOnSquare(move.EndSquare)
+		return landPiece.IsRook() && landPiece.IsWhite() == move.Piece.IsWhite()
+	}
+	return move.IsCastles()
 }
 
 func (move *Move) ToAlgebraic(board *Board) string {
-	if move.IsCastles() {
-		if move.EndSquare.File == 3 {
+	return move.ToAlgebraicWithOptions(board, nil)
+}
+
+// ToAlgebraicWithOptions writes the move in SAN with the pieces written as the options ask, e.g. in FAN
+func (move *Move) ToAlgebraicWithOptions(board *Board, options *NotationOptions) string {
+	if move.IsCastlesOn(board) {
+		if move.EndSquare.File < move.StartSquare.File {
 			return "O-O-O"
 		}
 		return "O-O"
@@ -67,10 +87,9 @@ func (move *Move) ToAlgebraic(board *Board) string {
 	algBuilder := strings.Builder{}
 	writeMoveStartSpecifier := func() {
 		if !move.Piece.IsPawn() {
-			pieceAlg := move.Piece.ToAlgebraic()
-			algBuilder.WriteString(pieceAlg)
+			algBuilder.WriteString(move.Piece.ToAlgebraicWithOptions(options))
 			sharedTargetSquareSquares := make([]*Square, 0)
-			if pieceAlg == "N" {
+			if move.Piece.IsKnight() {
 				sharedKnightSquares := []*Square{
 					{Rank: move.EndSquare.Rank + 2, File: move.EndSquare.File + 1},
 					{Rank: move.EndSquare.Rank + 1, File: move.EndSquare.File + 2},
@@ -92,11 +111,11 @@ func (move *Move) ToAlgebraic(board *Board) string {
 						sharedTargetSquareSquares = append(sharedTargetSquareSquares, sharedKnightSquare)
 					}
 				}
-			} else if pieceAlg == "B" || pieceAlg == "R" || pieceAlg == "Q" {
+			} else if move.Piece.IsBishop() || move.Piece.IsRook() || move.Piece.IsQueen() {
 				var dirFromEndSquare [][2]int
-				if pieceAlg == "B" {
+				if move.Piece.IsBishop() {
 					dirFromEndSquare = [][2]int{{1, 1}, {-1, -1}, {-1, 1}, {1, -1}}
-				} else if pieceAlg == "R" {
+				} else if move.Piece.IsRook() {
 					dirFromEndSquare = [][2]int{{1, 0}, {0, 1}, {-1, 0}, {0, -1}}
 				} else {
 					dirFromEndSquare = [][2]int{{1, 0}, {0, 1}, {-1, 0}, {0, -1}, {1, 1}, {-1, -1}, {-1, 1}, {1, -1}}
@@ -157,7 +176,7 @@ func (move *Move) ToAlgebraic(board *Board) string {
 	}
 	writePawnUpgradeSpecifier := func() {
 		if move.PawnUpgradedTo != EMPTY {
-			algBuilder.WriteString("=" + move.PawnUpgradedTo.ToAlgebraic())
+			algBuilder.WriteString("=" + move.PawnUpgradedTo.ToAlgebraicWithOptions(options))
 		}
 	}
 	writeCheckSpecifier := func() {
@@ -198,11 +217,14 @@ func (move *Move) ToLongAlgebraic() string {
 }
 
 func MoveFromAlgebraic(algMove string, priorBoard *Board) (*Move, error) {
+	if algMove == "O-O" || algMove == "O-O-O" {
+		return castleMoveFromAlgebraic(algMove, priorBoard)
+	}
 	originChars, targetChars, piece, upgradePiece := extractAlgebraicMoveInfo(algMove, priorBoard.IsWhiteTurn)
 
 	landSqr, landSquareErr := SquareFromAlgebraicCoords(targetChars)
 	if landSquareErr != nil {
-		return nil, fmt.Errorf("could not create move %s: could not make land square: %s", algMove, landSquareErr)
+		return nil, &MoveError{Notation: algMove, Err: &malformedSquareError{landSquareErr}}
 	}
 
 	var validMovesFromOrigin = make([]*Move, 0)
@@ -210,32 +232,33 @@ func MoveFromAlgebraic(algMove string, priorBoard *Board) (*Move, error) {
 	if len(originChars) == 2 {
 		originSqr, originSqrErr := SquareFromAlgebraicCoords(originChars)
 		if originSqrErr != nil {
-			return nil, fmt.Errorf("could not create move %s: could not make origin square: %s", algMove, originSqrErr)
+			return nil, &MoveError{Notation: algMove, Err: &malformedSquareError{originSqrErr}}
 		}
 		validMovesFromOrigin, movesErr = GetLegalMovesFromOrigin(priorBoard, originSqr)
 	} else if len(originChars) == 1 {
 		originChar := originChars[0]
+		var originSqrs []*Square
 		if originChar >= 'a' && originChar <= 'h' {
 			file := originChar - 'a' + 1
-			originSqr, originSqrErr := priorBoard.pieceSquareByFile(piece, file)
-			if originSqrErr != nil {
-				return nil, fmt.Errorf("could not create move %s: %s", algMove, originSqrErr)
-			}
-			validMovesFromOrigin, movesErr = GetLegalMovesFromOrigin(priorBoard, originSqr)
+			originSqrs = priorBoard.pieceSquaresByFile(piece, file)
 		} else if originChar >= '1' && originChar <= '8' {
 			rank := originChar - '1' + 1
-			originSqr, originSqrErr := priorBoard.pieceSquareByRank(piece, rank)
-			if originSqrErr != nil {
-				return nil, fmt.Errorf("could not create move %s: %s", algMove, originSqrErr)
-			}
-			validMovesFromOrigin, movesErr = GetLegalMovesFromOrigin(priorBoard, originSqr)
+			originSqrs = priorBoard.pieceSquaresByRank(piece, rank)
 		} else {
-			return nil, fmt.Errorf("malformed origin char %s in move %s", string(originChar), algMove)
+			return nil, &MoveError{Notation: algMove, Err: fmt.Errorf("%w, origin char %c", ErrMalformedNotation, originChar)}
+		}
+		if len(originSqrs) == 0 {
+			return nil, &MoveError{Notation: algMove, Piece: piece, Err: ErrNoPieceOnSquare}
+		}
+		for _, originSqr := range originSqrs {
+			var validMovesFromOriginSqr []*Move
+			validMovesFromOriginSqr, movesErr = GetLegalMovesFromOrigin(priorBoard, originSqr)
+			validMovesFromOrigin = append(validMovesFromOrigin, validMovesFromOriginSqr...)
 		}
 	} else {
 		pieceSqrs := priorBoard.pieceSquaresOnBoard(piece)
 		if len(pieceSqrs) == 0 {
-			return nil, fmt.Errorf("cannot create move, piece of type %s does not exist on %s", piece, priorBoard)
+			return nil, &MoveError{Notation: algMove, Piece: piece, Err: ErrNoPieceOnSquare}
 		}
 		for _, pieceSqr := range pieceSqrs {
 			var validMovesFromPieceSqr []*Move
@@ -252,7 +275,7 @@ func MoveFromAlgebraic(algMove string, priorBoard *Board) (*Move, error) {
 	}
 
 	if movesErr != nil {
-		return nil, fmt.Errorf("cannot create move, could not generate legal moves that match move %s on %s: %s", algMove, priorBoard, movesErr)
+		return nil, withMoveNotation(movesErr, algMove)
 	}
 	if len(validMoves) == 4 && upgradePiece != EMPTY {
 		for _, validMove := range validMoves {
@@ -263,30 +286,50 @@ func MoveFromAlgebraic(algMove string, priorBoard *Board) (*Move, error) {
 		}
 	}
 	if len(validMoves) > 1 {
-		return nil, fmt.Errorf("cannot create move, move repr %s is ambiguous. Rank and/or file needed on %s", algMove, priorBoard)
+		return nil, &MoveError{Notation: algMove, Piece: piece, Candidates: validMoves, Err: ErrAmbiguousMove}
 	}
 	if len(validMoves) == 0 {
-		return nil, fmt.Errorf("cannot create move, no legal moves exist with piece %s and landSqr %s on %s", piece, landSqr, priorBoard)
+		return nil, &MoveError{Notation: algMove, Piece: piece, Candidates: validMovesFromOrigin, Err: ErrIllegalMove}
 	}
 	return validMoves[0], nil
 }
 
+// castleMoveFromAlgebraic finds the castling move among the king's legal moves, since in Chess960 the king and
+// rook squares depend on the starting position
+func castleMoveFromAlgebraic(algMove string, priorBoard *Board) (*Move, error) {
+	isKingside := algMove == "O-O"
+	kingSquare := priorBoard.GetKingSquare(priorBoard.IsWhiteTurn)
+	if kingSquare == nil {
+		return nil, &MoveError{Notation: algMove, Err: ErrNoPieceOnSquare}
+	}
+	for _, move := range GetLegalMovesForKing(priorBoard) {
+		if move.IsCastlesOn(priorBoard) && (move.EndSquare.File > move.StartSquare.File) == isKingside {
+			return move, nil
+		}
+	}
+	return nil, &MoveError{Notation: algMove, Square: kingSquare, Piece: priorBoard.GetPieceOnSquare(kingSquare), Err: ErrIllegalMove}
+}
+
 func MoveFromLongAlgebraic(algMove string, priorBoard *Board) (*Move, error) {
 	originChars, targetChars, _, upgradePiece := extractAlgebraicMoveInfo(algMove, priorBoard.IsWhiteTurn)
 	if len(originChars) != 2 {
-		return nil, fmt.Errorf("cannot create move, long algebraic origin must be a square, got: %s", originChars)
+		return nil, &MoveError{Notation: algMove, Err: fmt.Errorf("%w, long algebraic origin must be a square, got %s", ErrMalformedNotation, originChars)}
 	}
 	originSqr, originSqrErr := SquareFromAlgebraicCoords(originChars)
 	if originSqrErr != nil {
-		return nil, fmt.Errorf("cannot create move, error reading origin square from move %s: %s", algMove, originSqrErr)
+		return nil, &MoveError{Notation: algMove, Err: &malformedSquareError{originSqrErr}}
 	}
 	landSqr, landSqrErr := SquareFromAlgebraicCoords(targetChars)
 	if landSqrErr != nil {
-		return nil, fmt.Errorf("cannot create move, error reading land square from move %s: %s", algMove, landSqrErr)
+		return nil, &MoveError{Notation: algMove, Err: &malformedSquareError{landSqrErr}}
+	}
+	piece := priorBoard.GetPieceOnSquare(originSqr)
+	if piece == EMPTY {
+		return nil, &MoveError{Notation: algMove, Square: originSqr, Err: ErrNoPieceOnSquare}
 	}
 	moves, movesErr := GetLegalMovesFromOrigin(priorBoard, originSqr)
 	if movesErr != nil {
-		return nil, fmt.Errorf("cannot create move, error getting moves from origin square %s on %s: %s", originChars, priorBoard, movesErr)
+		return nil, withMoveNotation(movesErr, algMove)
 	}
 	for _, move := range moves {
 		if move.EndSquare.Equal(landSqr) {
@@ -296,7 +339,7 @@ func MoveFromLongAlgebraic(algMove string, priorBoard *Board) (*Move, error) {
 			return move, nil
 		}
 	}
-	return nil, fmt.Errorf("cannot create move, could not find move from %s to %s on %s", originChars, targetChars, priorBoard)
+	return nil, &MoveError{Notation: algMove, Square: originSqr, Piece: piece, Candidates: moves, Err: ErrIllegalMove}
 }
 
 // extractAlgebraicMoveInfo supports both standard algebraic notation and long algebraic notation move formats
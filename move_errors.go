@@ -0,0 +1,85 @@
+package chess
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Errors returned when a move cannot be read or played. Move parsing and generation wrap them in a *MoveError
+// carrying the details, so match them with errors.Is and read the details with errors.As.
+var (
+	ErrMalformedNotation = errors.New("malformed notation")
+	ErrInvalidSquare     = errors.New("invalid square")
+	ErrNoPieceOnSquare   = errors.New("no matching piece")
+	ErrNotYourTurn       = errors.New("not the player's turn")
+	ErrIllegalMove       = errors.New("illegal move")
+	ErrAmbiguousMove     = errors.New("ambiguous move")
+)
+
+// MoveError describes why a move could not be read or played. Square and Piece are the origin of the move
+// when known. Candidates holds the legal moves that matched an ambiguous move, or the legal moves of the piece
+// for an illegal move, to offer as suggestions.
+type MoveError struct {
+	Notation   string
+	Square     *Square
+	Piece      Piece
+	Candidates []*Move
+	Err        error
+}
+
+func (moveErr *MoveError) Error() string {
+	var builder strings.Builder
+	builder.WriteString("invalid move")
+	if moveErr.Notation != "" {
+		builder.WriteString(" " + moveErr.Notation)
+	}
+	builder.WriteString(": " + moveErr.Err.Error())
+	if moveErr.Piece != EMPTY {
+		builder.WriteString(fmt.Sprintf(", piece %s", moveErr.Piece))
+	}
+	if moveErr.Square != nil {
+		builder.WriteString(" on " + moveErr.Square.ToAlgebraicCoords())
+	}
+	if len(moveErr.Candidates) > 0 {
+		candidateStrs := make([]string, len(moveErr.Candidates))
+		for idx, candidate := range moveErr.Candidates {
+			candidateStrs[idx] = candidate.ToLongAlgebraic()
+		}
+		builder.WriteString(", candidates " + strings.Join(candidateStrs, " "))
+	}
+	return builder.String()
+}
+
+func (moveErr *MoveError) Unwrap() error {
+	return moveErr.Err
+}
+
+// malformedSquareError is malformed notation caused by an unreadable square. It matches ErrMalformedNotation and
+// unwraps to the square error, so both stay matchable with errors.Is.
+type malformedSquareError struct {
+	squareErr error
+}
+
+func (err *malformedSquareError) Error() string {
+	return fmt.Sprintf("%s, %s", ErrMalformedNotation, err.squareErr)
+}
+
+func (err *malformedSquareError) Is(target error) bool {
+	return target == ErrMalformedNotation
+}
+
+func (err *malformedSquareError) Unwrap() error {
+	return err.squareErr
+}
+
+// withMoveNotation fills in the notation of a *MoveError raised while reading that notation
+func withMoveNotation(err error, notation string) error {
+	var moveErr *MoveError
+	if errors.As(err, &moveErr) && moveErr.Notation == "" {
+		moveErrCopy := *moveErr
+		moveErrCopy.Notation = notation
+		return &moveErrCopy
+	}
+	return err
+}
@@ -1,6 +1,8 @@
 package chess_test
 
 import (
+	"errors"
+
 	. "github.com/CameronHonis/chess"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -404,6 +406,16 @@ var _ = Describe("Move", func() {
 						Expect(MoveFromAlgebraic(algMove, board)).To(BeComparableTo(expMove))
 					})
 				})
+				When("the capturing pawn is not the first pawn on its file", func() {
+					BeforeEach(func() {
+						algMove = "dxe5"
+						board, _ = BoardFromFEN("rnbqkb1r/pppp1ppp/8/4n3/3P4/3P4/PPP2PPP/RNBQKBNR w KQkq - 0 1")
+					})
+					It("generates the correct move", func() {
+						expMove := &Move{WHITE_PAWN, &Square{4, 4}, &Square{5, 5}, BLACK_KNIGHT, make([]*Square, 0), EMPTY}
+						Expect(MoveFromAlgebraic(algMove, board)).To(BeComparableTo(expMove))
+					})
+				})
 			})
 		})
 		When("the move is a knight move", func() {
@@ -570,6 +582,62 @@ var _ = Describe("Move", func() {
 			Expect(MoveFromLongAlgebraic(longAlgMove, board)).To(BeComparableTo(expMove))
 		})
 	})
+
+	Describe("errors", func() {
+		It("reports malformed notation", func() {
+			_, err := MoveFromAlgebraic("Nz9", GetInitBoard())
+			Expect(errors.Is(err, ErrMalformedNotation)).To(BeTrue())
+			_, err = MoveFromLongAlgebraic("e2", GetInitBoard())
+			Expect(errors.Is(err, ErrMalformedNotation)).To(BeTrue())
+		})
+		It("reports the invalid square of malformed notation", func() {
+			_, err := MoveFromAlgebraic("Nz9", GetInitBoard())
+			Expect(errors.Is(err, ErrMalformedNotation)).To(BeTrue())
+			Expect(errors.Is(err, ErrInvalidSquare)).To(BeTrue())
+			_, err = MoveFromLongAlgebraic("e2e9", GetInitBoard())
+			Expect(errors.Is(err, ErrMalformedNotation)).To(BeTrue())
+			Expect(errors.Is(err, ErrInvalidSquare)).To(BeTrue())
+		})
+		It("reports an invalid square", func() {
+			_, err := SquareFromAlgebraicCoords("i9")
+			Expect(errors.Is(err, ErrInvalidSquare)).To(BeTrue())
+		})
+		It("reports an ambiguous move with its candidates", func() {
+			board, _ := BoardFromFEN("4k3/8/8/8/8/R6R/8/4K3 w - - 0 1")
+			_, err := MoveFromAlgebraic("Rd3", board)
+			Expect(errors.Is(err, ErrAmbiguousMove)).To(BeTrue())
+			var moveErr *MoveError
+			Expect(errors.As(err, &moveErr)).To(BeTrue())
+			Expect(moveErr.Piece).To(Equal(WHITE_ROOK))
+			Expect(moveErr.Candidates).To(HaveLen(2))
+		})
+		It("reports an illegal move with the piece's legal moves", func() {
+			_, err := MoveFromLongAlgebraic("g1g4", GetInitBoard())
+			Expect(errors.Is(err, ErrIllegalMove)).To(BeTrue())
+			var moveErr *MoveError
+			Expect(errors.As(err, &moveErr)).To(BeTrue())
+			Expect(moveErr.Square).To(Equal(&Square{1, 7}))
+			Expect(moveErr.Candidates).To(HaveLen(2))
+		})
+		It("reports a move by the side not to move", func() {
+			_, err := MoveFromLongAlgebraic("e7e5", GetInitBoard())
+			Expect(errors.Is(err, ErrNotYourTurn)).To(BeTrue())
+			_, err = GetLegalMovesFromOrigin(GetInitBoard(), &Square{7, 5})
+			Expect(errors.Is(err, ErrNotYourTurn)).To(BeTrue())
+		})
+		It("reports a missing piece", func() {
+			_, err := MoveFromLongAlgebraic("e4e5", GetInitBoard())
+			Expect(errors.Is(err, ErrNoPieceOnSquare)).To(BeTrue())
+			board, _ := BoardFromFEN("4k3/8/8/8/8/8/8/4K3 w - - 0 1")
+			_, err = MoveFromAlgebraic("Nf3", board)
+			Expect(errors.Is(err, ErrNoPieceOnSquare)).To(BeTrue())
+		})
+		It("reports an illegal move played in a game", func() {
+			game := NewGame()
+			err := game.Move(&Move{Piece: WHITE_PAWN, StartSquare: &Square{2, 5}, EndSquare: &Square{5, 5}})
+			Expect(errors.Is(err, ErrIllegalMove)).To(BeTrue())
+		})
+	})
 })
 
 //var _ = FIt("go-cmp test", func() {
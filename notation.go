@@ -0,0 +1,102 @@
+package chess
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// PieceLetters holds the letters a language writes pieces with in algebraic notation
+type PieceLetters struct {
+	King   string
+	Queen  string
+	Rook   string
+	Bishop string
+	Knight string
+}
+
+var (
+	ENGLISH_PIECE_LETTERS = &PieceLetters{King: "K", Queen: "Q", Rook: "R", Bishop: "B", Knight: "N"}
+	GERMAN_PIECE_LETTERS  = &PieceLetters{King: "K", Queen: "D", Rook: "T", Bishop: "L", Knight: "S"}
+	SPANISH_PIECE_LETTERS = &PieceLetters{King: "R", Queen: "D", Rook: "T", Bishop: "A", Knight: "C"}
+	FRENCH_PIECE_LETTERS  = &PieceLetters{King: "R", Queen: "D", Rook: "T", Bishop: "F", Knight: "C"}
+)
+
+// NotationOptions changes how pieces are written in algebraic notation. The zero value writes standard SAN.
+type NotationOptions struct {
+	// PieceLetters is nil for English letters
+	PieceLetters *PieceLetters
+	// IsFigurine writes pieces as Unicode chess glyphs in the moving side's colour (FAN), e.g. ♘f3
+	IsFigurine bool
+}
+
+func (options *NotationOptions) pieceLetters() *PieceLetters {
+	if options == nil || options.PieceLetters == nil {
+		return ENGLISH_PIECE_LETTERS
+	}
+	return options.PieceLetters
+}
+
+func (letters *PieceLetters) letter(piece Piece) string {
+	switch {
+	case piece.IsKnight():
+		return letters.Knight
+	case piece.IsBishop():
+		return letters.Bishop
+	case piece.IsRook():
+		return letters.Rook
+	case piece.IsQueen():
+		return letters.Queen
+	case piece.IsKing():
+		return letters.King
+	default:
+		return "P"
+	}
+}
+
+// ToAlgebraicWithOptions writes the piece letter, or the piece's glyph for figurine notation
+func (p Piece) ToAlgebraicWithOptions(options *NotationOptions) string {
+	if p == EMPTY || p > BLACK_KING {
+		panic(fmt.Sprintf("cannot convert invalid piece %s to algebraic notation", p))
+	}
+	if options != nil && options.IsFigurine {
+		return string([]rune("♙♘♗♖♕♔♟♞♝♜♛♚")[p-WHITE_PAWN])
+	}
+	return options.pieceLetters().letter(p)
+}
+
+// MoveFromAlgebraicWithOptions reads a move written with the given piece letters, or with figurines in either
+// colour
+func MoveFromAlgebraicWithOptions(algMove string, priorBoard *Board, options *NotationOptions) (*Move, error) {
+	move, err := MoveFromAlgebraic(toEnglishAlgebraic(algMove, options), priorBoard)
+	if err != nil {
+		return nil, withMoveNotation(err, algMove)
+	}
+	return move, nil
+}
+
+// toEnglishAlgebraic swaps localized piece letters and figurines for English piece letters
+func toEnglishAlgebraic(algMove string, options *NotationOptions) string {
+	letters := options.pieceLetters()
+	englishLetterByLetter := map[string]string{
+		letters.King:   "K",
+		letters.Queen:  "Q",
+		letters.Rook:   "R",
+		letters.Bishop: "B",
+		letters.Knight: "N",
+	}
+	if strings.HasPrefix(algMove, "O-O") {
+		return algMove
+	}
+	var englishBuilder strings.Builder
+	for _, algRune := range algMove {
+		if pieceLetter, ok := pieceLetterByFigurine[algRune]; ok {
+			englishBuilder.WriteString(pieceLetter)
+		} else if englishLetter, ok := englishLetterByLetter[string(algRune)]; ok && unicode.IsUpper(algRune) {
+			englishBuilder.WriteString(englishLetter)
+		} else {
+			englishBuilder.WriteRune(algRune)
+		}
+	}
+	return englishBuilder.String()
+}
@@ -0,0 +1,75 @@
+package chess_test
+
+import (
+	. "github.com/CameronHonis/chess"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Notation", func() {
+	var board *Board
+	var move *Move
+	BeforeEach(func() {
+		board, _ = BoardFromFEN("4k3/8/8/8/8/5n2/4P3/1N2K3 w - - 0 1")
+		move = &Move{WHITE_PAWN, &Square{2, 5}, &Square{3, 6}, BLACK_KNIGHT, []*Square{}, EMPTY}
+	})
+
+	Describe("::ToAlgebraicWithOptions", func() {
+		It("writes standard SAN without options", func() {
+			Expect(move.ToAlgebraicWithOptions(board, nil)).To(Equal("exf3"))
+			knightMove := &Move{WHITE_KNIGHT, &Square{1, 2}, &Square{3, 3}, EMPTY, []*Square{}, EMPTY}
+			Expect(knightMove.ToAlgebraicWithOptions(board, &NotationOptions{})).To(Equal("Nc3"))
+		})
+		It("writes figurines", func() {
+			knightMove := &Move{WHITE_KNIGHT, &Square{1, 2}, &Square{3, 3}, EMPTY, []*Square{}, EMPTY}
+			Expect(knightMove.ToAlgebraicWithOptions(board, &NotationOptions{IsFigurine: true})).To(Equal("♘c3"))
+		})
+		DescribeTable("writes localized piece letters", func(letters *PieceLetters, expSAN string) {
+			knightMove := &Move{WHITE_KNIGHT, &Square{1, 2}, &Square{3, 3}, EMPTY, []*Square{}, EMPTY}
+			Expect(knightMove.ToAlgebraicWithOptions(board, &NotationOptions{PieceLetters: letters})).To(Equal(expSAN))
+		},
+			Entry("German", GERMAN_PIECE_LETTERS, "Sc3"),
+			Entry("Spanish", SPANISH_PIECE_LETTERS, "Cc3"),
+			Entry("French", FRENCH_PIECE_LETTERS, "Cc3"),
+		)
+		It("writes localized promotion pieces", func() {
+			board, _ = BoardFromFEN("8/4P1k1/8/8/8/8/8/K7 w - - 0 1")
+			promotion := &Move{WHITE_PAWN, &Square{7, 5}, &Square{8, 5}, EMPTY, []*Square{}, WHITE_QUEEN}
+			Expect(promotion.ToAlgebraicWithOptions(board, &NotationOptions{PieceLetters: GERMAN_PIECE_LETTERS})).To(Equal("e8=D"))
+			Expect(promotion.ToAlgebraicWithOptions(board, &NotationOptions{IsFigurine: true})).To(Equal("e8=♕"))
+		})
+	})
+
+	Describe("#MoveFromAlgebraicWithOptions", func() {
+		DescribeTable("reads the localized notation back", func(algMove string, options *NotationOptions) {
+			expMove, err := MoveFromAlgebraic("Kd1", board)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(MoveFromAlgebraicWithOptions(algMove, board, options)).To(Equal(expMove))
+		},
+			Entry("Spanish king, which is an English rook letter", "Rd1", &NotationOptions{PieceLetters: SPANISH_PIECE_LETTERS}),
+			Entry("German", "Kd1", &NotationOptions{PieceLetters: GERMAN_PIECE_LETTERS}),
+			Entry("figurine", "♔d1", &NotationOptions{IsFigurine: true}),
+		)
+		It("reads localized promotions", func() {
+			board, _ = BoardFromFEN("8/4P1k1/8/8/8/8/8/K7 w - - 0 1")
+			move, err := MoveFromAlgebraicWithOptions("e8=D", board, &NotationOptions{PieceLetters: GERMAN_PIECE_LETTERS})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(move.PawnUpgradedTo).To(Equal(WHITE_QUEEN))
+		})
+		It("round trips every legal move", func() {
+			board, _ = BoardFromFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+			moves, err := GetLegalMoves(board)
+			Expect(err).ToNot(HaveOccurred())
+			for _, options := range []*NotationOptions{
+				{PieceLetters: FRENCH_PIECE_LETTERS},
+				{PieceLetters: SPANISH_PIECE_LETTERS},
+				{IsFigurine: true},
+			} {
+				for _, move := range moves {
+					algMove := move.ToAlgebraicWithOptions(board, options)
+					Expect(MoveFromAlgebraicWithOptions(algMove, board, options)).To(Equal(move), algMove)
+				}
+			}
+		})
+	})
+})
@@ -0,0 +1,274 @@
+package chess
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// OpeningBookMoveStats counts the games that played a move from a position. Games without a decisive or drawn
+// result count towards Count only, and the average rating is taken over the games where the player making the
+// move had an Elo tag.
+type OpeningBookMoveStats struct {
+	Move      *Move
+	Count     int
+	WhiteWins int
+	Draws     int
+	BlackWins int
+
+	ratingSum   int
+	ratingCount int
+}
+
+func (stats *OpeningBookMoveStats) AverageRating() float64 {
+	if stats.ratingCount == 0 {
+		return 0
+	}
+	return float64(stats.ratingSum) / float64(stats.ratingCount)
+}
+
+// polyglotWeight scores the move for the side that played it, two points a win and one a draw, as Polyglot does
+func (stats *OpeningBookMoveStats) polyglotWeight(isWhiteMove bool) int {
+	wins := stats.WhiteWins
+	if !isWhiteMove {
+		wins = stats.BlackWins
+	}
+	return 2*wins + stats.Draws
+}
+
+// openingBookPosition holds the moves played from a position, in the order they were first seen. board is the
+// first board seen with the position, which the moves are made on.
+type openingBookPosition struct {
+	board       *Board
+	moves       []*OpeningBookMoveStats
+	encodedIdxs map[uint16]int
+}
+
+// OpeningBookBuilder gathers move statistics from games into an opening book. Positions are keyed by their
+// Polyglot key, so move orders that transpose share their statistics.
+type OpeningBookBuilder struct {
+	// maxPly is the number of moves taken from the start of each game
+	maxPly int
+	// minCount leaves moves played fewer times out of the book
+	minCount       int
+	positionsByKey map[uint64]*openingBookPosition
+	startBoards    []*Board
+	startBoardKeys map[uint64]bool
+}
+
+func NewOpeningBookBuilder(maxPly int, minCount int) *OpeningBookBuilder {
+	return &OpeningBookBuilder{
+		maxPly:         maxPly,
+		minCount:       minCount,
+		positionsByKey: make(map[uint64]*openingBookPosition),
+		startBoards:    make([]*Board, 0),
+		startBoardKeys: make(map[uint64]bool),
+	}
+}
+
+// AddGame counts the first moves of the game's main line, along with the game's result and the players' ratings
+func (builder *OpeningBookBuilder) AddGame(game *PGNGame) {
+	startBoard := game.Boards[0]
+	if startKey := startBoard.PolyglotKey(); !builder.startBoardKeys[startKey] {
+		builder.startBoardKeys[startKey] = true
+		builder.startBoards = append(builder.startBoards, startBoard)
+	}
+	whiteRating := pgnRatingTag(game, "WhiteElo")
+	blackRating := pgnRatingTag(game, "BlackElo")
+	for ply, move := range game.Moves {
+		if ply >= builder.maxPly {
+			break
+		}
+		board := game.Boards[ply]
+		stats := builder.moveStats(board, move)
+		stats.Count++
+		switch game.Result {
+		case "1-0":
+			stats.WhiteWins++
+		case "0-1":
+			stats.BlackWins++
+		case "1/2-1/2":
+			stats.Draws++
+		}
+		rating := whiteRating
+		if !board.IsWhiteTurn {
+			rating = blackRating
+		}
+		if rating > 0 {
+			stats.ratingSum += rating
+			stats.ratingCount++
+		}
+	}
+}
+
+// AddGames adds every game the reader holds, skipping games that can't be parsed. It returns the number of games
+// added, and an error only when reading from the stream fails.
+func (builder *OpeningBookBuilder) AddGames(reader *PGNReader) (int, error) {
+	gameCount := 0
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			return gameCount, nil
+		}
+		if err != nil {
+			return gameCount, err
+		}
+		if record.Err != nil {
+			continue
+		}
+		builder.AddGame(record.Tree.ToPGNGame())
+		gameCount++
+	}
+}
+
+func (builder *OpeningBookBuilder) moveStats(board *Board, move *Move) *OpeningBookMoveStats {
+	key := board.PolyglotKey()
+	position, ok := builder.positionsByKey[key]
+	if !ok {
+		position = &openingBookPosition{
+			board:       board,
+			moves:       make([]*OpeningBookMoveStats, 0),
+			encodedIdxs: make(map[uint16]int),
+		}
+		builder.positionsByKey[key] = position
+	}
+	encodedMove := encodePolyglotMove(move, board)
+	if idx, ok := position.encodedIdxs[encodedMove]; ok {
+		return position.moves[idx]
+	}
+	stats := &OpeningBookMoveStats{Move: move}
+	position.encodedIdxs[encodedMove] = len(position.moves)
+	position.moves = append(position.moves, stats)
+	return stats
+}
+
+// Moves returns the statistics of the moves played from the board that were played often enough to make the
+// book, the most played first
+func (builder *OpeningBookBuilder) Moves(board *Board) []*OpeningBookMoveStats {
+	position, ok := builder.positionsByKey[board.PolyglotKey()]
+	if !ok {
+		return []*OpeningBookMoveStats{}
+	}
+	moves := make([]*OpeningBookMoveStats, 0, len(position.moves))
+	for _, stats := range position.moves {
+		if stats.Count >= builder.minCount {
+			moves = append(moves, stats)
+		}
+	}
+	sort.SliceStable(moves, func(i, j int) bool {
+		return moves[i].Count > moves[j].Count
+	})
+	return moves
+}
+
+// PolyglotEntries returns the book as Polyglot entries, sorted by key and then by weight. Weights are scaled down
+// where needed to fit, keeping their proportions within each position.
+func (builder *OpeningBookBuilder) PolyglotEntries() []PolyglotEntry {
+	entries := make([]PolyglotEntry, 0)
+	for key, position := range builder.positionsByKey {
+		moves := builder.Moves(position.board)
+		maxWeight := 0
+		for _, stats := range moves {
+			if weight := stats.polyglotWeight(position.board.IsWhiteTurn); weight > maxWeight {
+				maxWeight = weight
+			}
+		}
+		scale := 1.0
+		if maxWeight > math.MaxUint16 {
+			scale = float64(math.MaxUint16) / float64(maxWeight)
+		}
+		for _, stats := range moves {
+			weight := float64(stats.polyglotWeight(position.board.IsWhiteTurn)) * scale
+			entries = append(entries, PolyglotEntry{
+				Key:    key,
+				Move:   encodePolyglotMove(stats.Move, position.board),
+				Weight: uint16(weight),
+			})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Key != entries[j].Key {
+			return entries[i].Key < entries[j].Key
+		}
+		if entries[i].Weight != entries[j].Weight {
+			return entries[i].Weight > entries[j].Weight
+		}
+		return entries[i].Move < entries[j].Move
+	})
+	return entries
+}
+
+// WritePolyglot writes the book in Polyglot's .bin format
+func (builder *OpeningBookBuilder) WritePolyglot(writer io.Writer) error {
+	return WritePolyglotBook(writer, builder.PolyglotEntries())
+}
+
+// OpeningBookTree is the book laid out as a tree of moves from one of the positions the games started from
+type OpeningBookTree struct {
+	FEN   string             `json:"fen"`
+	Moves []*OpeningBookNode `json:"moves"`
+}
+
+type OpeningBookNode struct {
+	SAN           string             `json:"san"`
+	UCI           string             `json:"uci"`
+	Count         int                `json:"count"`
+	WhiteWins     int                `json:"whiteWins"`
+	Draws         int                `json:"draws"`
+	BlackWins     int                `json:"blackWins"`
+	AverageRating float64            `json:"averageRating"`
+	Moves         []*OpeningBookNode `json:"moves"`
+}
+
+// Trees lays the book out as trees of moves, one from each position the games started from. A position reached
+// by several move orders appears under each of them, with the statistics of all of them.
+func (builder *OpeningBookBuilder) Trees() []*OpeningBookTree {
+	trees := make([]*OpeningBookTree, 0, len(builder.startBoards))
+	for _, startBoard := range builder.startBoards {
+		trees = append(trees, &OpeningBookTree{
+			FEN:   startBoard.ToFEN(),
+			Moves: builder.treeNodes(startBoard, 0),
+		})
+	}
+	return trees
+}
+
+func (builder *OpeningBookBuilder) treeNodes(board *Board, ply int) []*OpeningBookNode {
+	nodes := make([]*OpeningBookNode, 0)
+	if ply >= builder.maxPly {
+		return nodes
+	}
+	for _, stats := range builder.Moves(board) {
+		nodes = append(nodes, &OpeningBookNode{
+			SAN:           stats.Move.ToAlgebraic(board),
+			UCI:           stats.Move.ToLongAlgebraic(),
+			Count:         stats.Count,
+			WhiteWins:     stats.WhiteWins,
+			Draws:         stats.Draws,
+			BlackWins:     stats.BlackWins,
+			AverageRating: stats.AverageRating(),
+			Moves:         builder.treeNodes(GetBoardFromMove(board, stats.Move), ply+1),
+		})
+	}
+	return nodes
+}
+
+// WriteJSON writes the trees of moves as JSON, see Trees
+func (builder *OpeningBookBuilder) WriteJSON(writer io.Writer) error {
+	return json.NewEncoder(writer).Encode(builder.Trees())
+}
+
+// pgnRatingTag reads an Elo tag, returning 0 when the tag is missing or isn't a rating
+func pgnRatingTag(game *PGNGame, name string) int {
+	value, ok := game.GetTag(name)
+	if !ok {
+		return 0
+	}
+	rating, err := strconv.Atoi(value)
+	if err != nil || rating < 0 {
+		return 0
+	}
+	return rating
+}
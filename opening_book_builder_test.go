@@ -0,0 +1,145 @@
+package chess_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	. "github.com/CameronHonis/chess"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// the first and third games transpose into the same position after four moves
+const OPENING_BOOK_PGN = `[WhiteElo "2400"]
+[BlackElo "2200"]
+[Result "1-0"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bc4 Bc5 4. O-O Nf6 1-0
+
+[WhiteElo "2000"]
+[BlackElo "2100"]
+[Result "0-1"]
+
+1. e4 c5 2. Nf3 d6 0-1
+
+[Result "1/2-1/2"]
+
+1. Nf3 Nc6 2. e4 e5 3. Bb5 1/2-1/2
+
+[Result "*"]
+
+1. e4 e5 2. Ke3 *
+`
+
+func boardAfter(sans ...string) *Board {
+	board := GetInitBoard()
+	for _, san := range sans {
+		move, err := MoveFromAlgebraic(san, board)
+		Expect(err).ToNot(HaveOccurred())
+		board = GetBoardFromMove(board, move)
+	}
+	return board
+}
+
+func statsNotations(board *Board, moves []*OpeningBookMoveStats) []string {
+	notations := make([]string, 0, len(moves))
+	for _, stats := range moves {
+		notations = append(notations, stats.Move.ToAlgebraic(board))
+	}
+	return notations
+}
+
+var _ = Describe("OpeningBookBuilder", func() {
+	var builder *OpeningBookBuilder
+	buildBook := func(maxPly int, minCount int) {
+		builder = NewOpeningBookBuilder(maxPly, minCount)
+		gameCount, err := builder.AddGames(NewPGNReader(strings.NewReader(OPENING_BOOK_PGN)))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(gameCount).To(Equal(3))
+	}
+
+	Describe("::Moves", func() {
+		It("counts the games, results and ratings of each move", func() {
+			buildBook(8, 1)
+			moves := builder.Moves(GetInitBoard())
+			Expect(statsNotations(GetInitBoard(), moves)).To(Equal([]string{"e4", "Nf3"}))
+			Expect(moves[0].Count).To(Equal(2))
+			Expect(moves[0].WhiteWins).To(Equal(1))
+			Expect(moves[0].BlackWins).To(Equal(1))
+			Expect(moves[0].AverageRating()).To(Equal(2200.0))
+			Expect(moves[1].Draws).To(Equal(1))
+			Expect(moves[1].AverageRating()).To(Equal(0.0))
+
+			afterE4 := boardAfter("e4")
+			Expect(statsNotations(afterE4, builder.Moves(afterE4))).To(Equal([]string{"e5", "c5"}))
+			Expect(builder.Moves(afterE4)[0].AverageRating()).To(Equal(2200.0))
+		})
+		It("shares the moves of positions reached by different move orders", func() {
+			buildBook(8, 1)
+			board := boardAfter("e4", "e5", "Nf3", "Nc6")
+			Expect(statsNotations(board, builder.Moves(board))).To(Equal([]string{"Bc4", "Bb5"}))
+		})
+		It("stops counting at the ply depth", func() {
+			buildBook(2, 1)
+			Expect(builder.Moves(boardAfter("e4", "e5"))).To(BeEmpty())
+			Expect(builder.Moves(boardAfter("e4"))).To(HaveLen(2))
+		})
+		It("leaves out moves played fewer times than the minimum", func() {
+			buildBook(8, 2)
+			Expect(statsNotations(GetInitBoard(), builder.Moves(GetInitBoard()))).To(Equal([]string{"e4"}))
+			Expect(builder.Moves(boardAfter("e4"))).To(BeEmpty())
+		})
+	})
+
+	Describe("::WritePolyglot", func() {
+		var book *PolyglotBook
+		BeforeEach(func() {
+			buildBook(8, 1)
+			var buf bytes.Buffer
+			Expect(builder.WritePolyglot(&buf)).To(Succeed())
+			var err error
+			book, err = ReadPolyglotBook(&buf)
+			Expect(err).ToNot(HaveOccurred())
+		})
+		It("weights the moves by the results of the side playing them", func() {
+			board := GetInitBoard()
+			bookMoves, err := book.Moves(board)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(bookMoveNotations(board, bookMoves)).To(Equal([]string{"e4", "Nf3"}))
+			Expect(bookMoves[0].Weight).To(Equal(uint16(2)))
+			Expect(bookMoves[1].Weight).To(Equal(uint16(1)))
+
+			afterE4 := boardAfter("e4")
+			bookMoves, err = book.Moves(afterE4)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(bookMoveNotations(afterE4, bookMoves)).To(Equal([]string{"c5", "e5"}))
+		})
+		It("writes castling so that it reads back", func() {
+			board := boardAfter("e4", "e5", "Nf3", "Nc6", "Bc4", "Bc5")
+			bookMoves, err := book.Moves(board)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(bookMoveNotations(board, bookMoves)).To(Equal([]string{"O-O"}))
+		})
+	})
+
+	Describe("::WriteJSON", func() {
+		It("writes a tree of moves from the start position", func() {
+			buildBook(3, 1)
+			var buf bytes.Buffer
+			Expect(builder.WriteJSON(&buf)).To(Succeed())
+			var trees []*OpeningBookTree
+			Expect(json.Unmarshal(buf.Bytes(), &trees)).To(Succeed())
+			Expect(trees).To(HaveLen(1))
+			Expect(trees[0].FEN).To(Equal(GetInitBoard().ToFEN()))
+			e4Node := trees[0].Moves[0]
+			Expect(e4Node.SAN).To(Equal("e4"))
+			Expect(e4Node.UCI).To(Equal("e2e4"))
+			Expect(e4Node.Count).To(Equal(2))
+			Expect(e4Node.Moves).To(HaveLen(2))
+			Expect(e4Node.Moves[0].SAN).To(Equal("e5"))
+			Expect(e4Node.Moves[0].Moves[0].SAN).To(Equal("Nf3"))
+			Expect(e4Node.Moves[0].Moves[0].Moves).To(BeEmpty())
+		})
+	})
+})
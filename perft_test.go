@@ -16,8 +16,6 @@ const PRINT_LEAFS = false
 const FOCUS_TEST_IDX = -1
 const MAX_DEPTH = 3
 
-var scanner *bufio.Scanner
-
 func perft(board *chess.Board, depth int) int {
 	return _perft(board, depth, make([]*chess.Move, 0))
 }
@@ -84,25 +82,28 @@ func parsePerftLine(line string) (fen string, depthNodeCntPairs [][2]int) {
 	return fen, depthNodeCntPairs
 }
 
-func perftFromFile() {
+// readPerftLines reads the perft data file, one FEN with its node counts by depth per line
+func readPerftLines() []string {
 	file, err := os.Open("./perft")
 	if err != nil {
 		log.Fatalf("failed to open file: %s", err)
 	}
+	defer file.Close()
 
-	scanner = bufio.NewScanner(file)
-	scanner.Split(bufio.ScanLines)
-
-	testIdx := 0
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
-		currTestIdx := testIdx
-		testIdx++
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
 
+func perftFromFile() {
+	for currTestIdx, line := range readPerftLines() {
 		shouldSkipTest := FOCUS_TEST_IDX >= 0 && FOCUS_TEST_IDX != currTestIdx
 		if shouldSkipTest {
 			continue
 		}
-		line := scanner.Text()
 		fmt.Printf("[TEST %d] %s\n", currTestIdx, line)
 		fen, depthNodeCntPairs := parsePerftLine(line)
 
@@ -129,8 +130,6 @@ func perftFromFile() {
 			}
 		}
 	}
-
-	_ = file.Close()
 }
 
 var _ = It("perft", func() {
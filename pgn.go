@@ -0,0 +1,448 @@
+package chess
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const PGN_MAX_LINE_LENGTH = 80
+
+// PGN_SEVEN_TAG_ROSTER lists the tags every exported game carries, in the order they are written.
+var PGN_SEVEN_TAG_ROSTER = []string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
+
+// PGN_VARIANT_CHESS960 is the value of the Variant tag written for Chess960 games
+const PGN_VARIANT_CHESS960 = "Chess960"
+
+type PGNTag struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// PGNGame is a single game read from PGN. Boards always holds one more entry than Moves: Boards[0] is the
+// position the game started from and Boards[i+1] is the position reached after Moves[i].
+type PGNGame struct {
+	Tags   []*PGNTag `json:"tags"`
+	Moves  []*Move   `json:"moves"`
+	Boards []*Board  `json:"boards"`
+	Result string    `json:"result"`
+}
+
+func (game *PGNGame) GetTag(name string) (string, bool) {
+	for _, tag := range game.Tags {
+		if tag.Name == name {
+			return tag.Value, true
+		}
+	}
+	return "", false
+}
+
+func (game *PGNGame) FinalBoard() *Board {
+	return game.Boards[len(game.Boards)-1]
+}
+
+func (game *PGNGame) ToPGN() (string, error) {
+	tree, treeErr := gameTreeFromMoves(game.Boards[0], game.Moves)
+	if treeErr != nil {
+		return "", treeErr
+	}
+	tree.Tags = game.Tags
+	tree.Result = game.Result
+	return tree.ToPGN()
+}
+
+// ParsePGN reads a single game in PGN import format. Every SAN token is replayed through MoveFromAlgebraic and
+// GetBoardFromMove, starting from the FEN tag if one is present. Only the main line is kept; use ParsePGNTree
+// to also keep comments, NAGs and variations.
+func ParsePGN(pgn string) (*PGNGame, error) {
+	tree, err := ParsePGNTree(pgn)
+	if err != nil {
+		return nil, err
+	}
+	return tree.ToPGNGame(), nil
+}
+
+// PGNFromMoves writes a game in PGN export format. The Seven Tag Roster is always written first, filling in
+// "?" for any missing values, followed by the remaining tags in ASCII order. Games that don't start from the
+// initial position get SetUp and FEN tags. The result is taken from the final board when the game ended on
+// the board, otherwise from the Result tag, if given.
+func PGNFromMoves(startBoard *Board, moves []*Move, tags []*PGNTag) (string, error) {
+	tree, treeErr := gameTreeFromMoves(startBoard, moves)
+	if treeErr != nil {
+		return "", treeErr
+	}
+	tree.Tags = tags
+	return tree.ToPGN()
+}
+
+func gameTreeFromMoves(startBoard *Board, moves []*Move) (*GameTree, error) {
+	tree := NewGameTree(startBoard)
+	node := tree.Root
+	for plyIdx, move := range moves {
+		var moveErr error
+		node, moveErr = node.AddVariation(move)
+		if moveErr != nil {
+			return nil, fmt.Errorf("cannot write PGN, move at ply %d: %w", plyIdx+1, moveErr)
+		}
+	}
+	return tree, nil
+}
+
+// writePGN lays out the tag pair section followed by the movetext, which must already end with the result token
+func writePGN(tags []*PGNTag, startBoard *Board, result string, movetext *pgnLineWriter) string {
+	tagValueByName := make(map[string]string)
+	for _, tag := range tags {
+		tagValueByName[tag.Name] = tag.Value
+	}
+	if startBoard.IsChess960 {
+		tagValueByName["Variant"] = PGN_VARIANT_CHESS960
+	}
+	if !startBoard.IsInitBoard() || startBoard.IsChess960 {
+		tagValueByName["SetUp"] = "1"
+		tagValueByName["FEN"] = startBoard.ToFEN()
+	}
+	tagValueByName["Result"] = result
+
+	var pgnBuilder strings.Builder
+	writeTag := func(name string) {
+		value := tagValueByName[name]
+		value = strings.ReplaceAll(value, `\`, `\\`)
+		value = strings.ReplaceAll(value, `"`, `\"`)
+		pgnBuilder.WriteString(fmt.Sprintf("[%s \"%s\"]\n", name, value))
+	}
+	for _, name := range PGN_SEVEN_TAG_ROSTER {
+		if _, ok := tagValueByName[name]; !ok {
+			if name == "Date" {
+				tagValueByName[name] = "????.??.??"
+			} else {
+				tagValueByName[name] = "?"
+			}
+		}
+		writeTag(name)
+	}
+	otherTagNames := make([]string, 0)
+	for name := range tagValueByName {
+		isRosterTag := false
+		for _, rosterName := range PGN_SEVEN_TAG_ROSTER {
+			if name == rosterName {
+				isRosterTag = true
+				break
+			}
+		}
+		if !isRosterTag {
+			otherTagNames = append(otherTagNames, name)
+		}
+	}
+	sort.Strings(otherTagNames)
+	for _, name := range otherTagNames {
+		writeTag(name)
+	}
+	pgnBuilder.WriteRune('\n')
+	pgnBuilder.WriteString(movetext.String())
+	pgnBuilder.WriteRune('\n')
+	return pgnBuilder.String()
+}
+
+func pgnResultFromBoardResult(result BoardResult) string {
+	switch result {
+	case BOARD_RESULT_WHITE_WINS_BY_CHECKMATE:
+		return "1-0"
+	case BOARD_RESULT_BLACK_WINS_BY_CHECKMATE:
+		return "0-1"
+	case BOARD_RESULT_IN_PROGRESS, "":
+		return "*"
+	default:
+		return "1/2-1/2"
+	}
+}
+
+// pgnLineWriter collects movetext tokens and joins them with single spaces, wrapping lines at
+// PGN_MAX_LINE_LENGTH. A move number and its SAN are written as a single token so they never get split across
+// lines, and parentheses are attached to the token they enclose.
+type pgnLineWriter struct {
+	tokens       []string
+	isLineEnds   []bool
+	pendingParen bool
+}
+
+func (writer *pgnLineWriter) writeToken(token string) {
+	writer.appendToken(token, false)
+}
+
+// writeLineComment writes a ";" comment, which always runs to the end of its line
+func (writer *pgnLineWriter) writeLineComment(text string) {
+	writer.appendToken(";"+text, true)
+}
+
+func (writer *pgnLineWriter) openParen() {
+	if writer.pendingParen {
+		writer.appendToken("", false)
+	}
+	writer.pendingParen = true
+}
+
+func (writer *pgnLineWriter) closeParen() {
+	lastIdx := len(writer.tokens) - 1
+	if writer.pendingParen || lastIdx < 0 || writer.isLineEnds[lastIdx] {
+		writer.appendToken(")", false)
+		return
+	}
+	writer.tokens[lastIdx] += ")"
+}
+
+func (writer *pgnLineWriter) appendToken(token string, isLineEnd bool) {
+	if writer.pendingParen {
+		token = "(" + token
+		writer.pendingParen = false
+	}
+	writer.tokens = append(writer.tokens, token)
+	writer.isLineEnds = append(writer.isLineEnds, isLineEnd)
+}
+
+func (writer *pgnLineWriter) String() string {
+	var builder strings.Builder
+	lineLength := 0
+	for tokenIdx, token := range writer.tokens {
+		tokenLength := utf8.RuneCountInString(token)
+		if lineLength > 0 && lineLength+1+tokenLength > PGN_MAX_LINE_LENGTH {
+			builder.WriteRune('\n')
+			lineLength = 0
+		} else if lineLength > 0 {
+			builder.WriteRune(' ')
+			lineLength++
+		}
+		builder.WriteString(token)
+		lineLength += tokenLength
+		if writer.isLineEnds[tokenIdx] && tokenIdx < len(writer.tokens)-1 {
+			builder.WriteRune('\n')
+			lineLength = 0
+		}
+	}
+	return builder.String()
+}
+
+func parsePGNTags(tokens []*pgnToken) ([]*PGNTag, int, error) {
+	tags := make([]*PGNTag, 0)
+	idx := 0
+	for idx < len(tokens) {
+		// comments between tag pairs are dropped, while those after the last tag pair belong to the movetext
+		nextIdx := idx
+		for nextIdx < len(tokens) && (tokens[nextIdx].tokenType == pgnTokenComment || tokens[nextIdx].tokenType == pgnTokenLineComment) {
+			nextIdx++
+		}
+		if nextIdx == len(tokens) || tokens[nextIdx].tokenType != pgnTokenLeftBracket {
+			break
+		}
+		idx = nextIdx
+		if idx+3 >= len(tokens) {
+			return nil, idx, fmt.Errorf("invalid PGN: unterminated tag pair")
+		}
+		nameToken, valueToken, closeToken := tokens[idx+1], tokens[idx+2], tokens[idx+3]
+		if nameToken.tokenType != pgnTokenSymbol {
+			return nil, idx, fmt.Errorf("invalid PGN: expected tag name, got %s", nameToken.value)
+		}
+		if valueToken.tokenType != pgnTokenString {
+			return nil, idx, fmt.Errorf("invalid PGN: expected quoted value for tag %s, got %s", nameToken.value, valueToken.value)
+		}
+		if closeToken.tokenType != pgnTokenRightBracket {
+			return nil, idx, fmt.Errorf("invalid PGN: expected ']' to close tag %s, got %s", nameToken.value, closeToken.value)
+		}
+		tags = append(tags, &PGNTag{nameToken.value, valueToken.value})
+		idx += 4
+	}
+	return tags, idx, nil
+}
+
+func startBoardFromPGNTags(tags []*PGNTag) (*Board, error) {
+	var fen string
+	hasFEN := false
+	isChess960 := false
+	for _, tag := range tags {
+		if tag.Name == "FEN" {
+			fen = tag.Value
+			hasFEN = true
+		} else if tag.Name == "Variant" {
+			isChess960 = isPGNChess960Variant(tag.Value)
+		}
+	}
+	if !hasFEN {
+		if isChess960 {
+			return Chess960StartBoard(CHESS960_STANDARD_POSITION_INDEX)
+		}
+		return GetInitBoard(), nil
+	}
+	var board *Board
+	var err error
+	if isChess960 {
+		board, err = Chess960BoardFromFEN(fen)
+	} else {
+		board, err = BoardFromFEN(fen)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid PGN: could not read FEN tag: %w", err)
+	}
+	return board, nil
+}
+
+func isPGNChess960Variant(variant string) bool {
+	switch strings.ToLower(strings.TrimSpace(variant)) {
+	case "chess960", "chess 960", "fischerandom", "fischer random", "960":
+		return true
+	default:
+		return false
+	}
+}
+
+func isPGNResult(value string) bool {
+	return value == "1-0" || value == "0-1" || value == "1/2-1/2" || value == "*"
+}
+
+func isPGNMoveNumber(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return len(value) > 0
+}
+
+// sanFromPGNToken strips the check and checkmate indicators, which MoveFromAlgebraic does not expect on
+// castling moves. Suffix annotations like "!?" are lexed as their own tokens and never reach here.
+func sanFromPGNToken(value string) string {
+	return strings.TrimRight(value, "+#")
+}
+
+type pgnTokenType uint8
+
+const (
+	pgnTokenSymbol pgnTokenType = iota
+	pgnTokenString
+	pgnTokenPeriod
+	pgnTokenAsterisk
+	pgnTokenLeftBracket
+	pgnTokenRightBracket
+	pgnTokenLeftParen
+	pgnTokenRightParen
+	pgnTokenComment
+	pgnTokenLineComment
+	pgnTokenNAG
+	pgnTokenSuffix
+)
+
+type pgnToken struct {
+	tokenType pgnTokenType
+	value     string
+}
+
+func tokenizePGN(pgn string) ([]*pgnToken, error) {
+	tokens := make([]*pgnToken, 0)
+	runes := []rune(pgn)
+	isLineStart := true
+	idx := 0
+	readUntil := func(stop rune) (string, bool) {
+		start := idx
+		for idx < len(runes) && runes[idx] != stop {
+			idx++
+		}
+		return string(runes[start:idx]), idx < len(runes)
+	}
+	for idx < len(runes) {
+		r := runes[idx]
+		if isLineStart && r == '%' {
+			// escape mechanism, the rest of the line is ignored
+			readUntil('\n')
+			continue
+		}
+		isLineStart = r == '\n'
+		if unicode.IsSpace(r) {
+			idx++
+			continue
+		}
+		switch r {
+		case '[':
+			tokens = append(tokens, &pgnToken{pgnTokenLeftBracket, "["})
+			idx++
+		case ']':
+			tokens = append(tokens, &pgnToken{pgnTokenRightBracket, "]"})
+			idx++
+		case '(':
+			tokens = append(tokens, &pgnToken{pgnTokenLeftParen, "("})
+			idx++
+		case ')':
+			tokens = append(tokens, &pgnToken{pgnTokenRightParen, ")"})
+			idx++
+		case '.':
+			tokens = append(tokens, &pgnToken{pgnTokenPeriod, "."})
+			idx++
+		case '*':
+			tokens = append(tokens, &pgnToken{pgnTokenAsterisk, "*"})
+			idx++
+		case '"':
+			idx++
+			var valueBuilder strings.Builder
+			isClosed := false
+			for idx < len(runes) {
+				c := runes[idx]
+				idx++
+				if c == '\\' && idx < len(runes) {
+					valueBuilder.WriteRune(runes[idx])
+					idx++
+					continue
+				}
+				if c == '"' {
+					isClosed = true
+					break
+				}
+				valueBuilder.WriteRune(c)
+			}
+			if !isClosed {
+				return nil, fmt.Errorf("invalid PGN: unterminated string")
+			}
+			tokens = append(tokens, &pgnToken{pgnTokenString, valueBuilder.String()})
+		case '{':
+			idx++
+			comment, isClosed := readUntil('}')
+			if !isClosed {
+				return nil, fmt.Errorf("invalid PGN: unterminated comment")
+			}
+			idx++
+			tokens = append(tokens, &pgnToken{pgnTokenComment, comment})
+		case ';':
+			idx++
+			comment, _ := readUntil('\n')
+			tokens = append(tokens, &pgnToken{pgnTokenLineComment, comment})
+		case '$':
+			idx++
+			start := idx
+			for idx < len(runes) && unicode.IsDigit(runes[idx]) {
+				idx++
+			}
+			if start == idx {
+				return nil, fmt.Errorf("invalid PGN: expected digits after '$'")
+			}
+			tokens = append(tokens, &pgnToken{pgnTokenNAG, string(runes[start:idx])})
+		case '!', '?':
+			start := idx
+			for idx < len(runes) && (runes[idx] == '!' || runes[idx] == '?') {
+				idx++
+			}
+			tokens = append(tokens, &pgnToken{pgnTokenSuffix, string(runes[start:idx])})
+		default:
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				return nil, fmt.Errorf("invalid PGN: unexpected character '%c'", r)
+			}
+			start := idx
+			for idx < len(runes) && isPGNSymbolRune(runes[idx]) {
+				idx++
+			}
+			tokens = append(tokens, &pgnToken{pgnTokenSymbol, string(runes[start:idx])})
+		}
+	}
+	return tokens, nil
+}
+
+func isPGNSymbolRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_+#=:-/", r)
+}
@@ -0,0 +1,160 @@
+package chess
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PGNRecord is a single game read by a PGNReader. When the game can't be parsed, Tree is nil and Err explains
+// why; the reader itself carries on with the next game.
+type PGNRecord struct {
+	Index int
+	// Offset is the position of the game's first byte, counted from the start of the reader. Together with
+	// Length it spans the game's text, excluding any blank lines around it.
+	Offset int64
+	Length int64
+	PGN    string
+	Tree   *GameTree
+	Err    error
+}
+
+// PGNReader splits a stream of PGN games, such as a database export, into individual games without loading
+// the whole stream into memory. A new game starts at the first tag pair following movetext, or after a line
+// that ends in a game termination marker.
+type PGNReader struct {
+	reader      *bufio.Reader
+	offset      int64
+	gameCount   int
+	pendingLine string
+	isEOF       bool
+}
+
+func NewPGNReader(reader io.Reader) *PGNReader {
+	return &PGNReader{
+		reader: bufio.NewReader(reader),
+	}
+}
+
+// Next reads and parses the next game. It returns io.EOF once the stream holds no more games, and any other
+// error only when reading from the stream itself fails.
+func (reader *PGNReader) Next() (*PGNRecord, error) {
+	var gameBuilder strings.Builder
+	var gameOffset int64
+	var contentLength int
+	hasStarted := false
+	hasMovetext := false
+	isInComment := false
+	for {
+		line, lineErr := reader.readLine()
+		if lineErr == io.EOF {
+			break
+		}
+		if lineErr != nil {
+			return nil, lineErr
+		}
+		trimmedLine := strings.TrimSpace(line)
+		if !hasStarted {
+			if trimmedLine == "" {
+				reader.offset += int64(len(line))
+				continue
+			}
+			hasStarted = true
+			gameOffset = reader.offset
+		}
+		isTagLine := !isInComment && strings.HasPrefix(trimmedLine, "[")
+		if isTagLine && hasMovetext {
+			reader.pendingLine = line
+			break
+		}
+		reader.offset += int64(len(line))
+		gameBuilder.WriteString(line)
+		if trimmedLine != "" {
+			contentLength = gameBuilder.Len() - (len(line) - len(strings.TrimRight(line, " \t\r\n")))
+		}
+		if isTagLine || strings.HasPrefix(line, "%") {
+			continue
+		}
+		var movetext string
+		movetext, isInComment = pgnMovetextOutsideComments(line, isInComment)
+		movetextFields := strings.Fields(movetext)
+		if len(movetextFields) == 0 {
+			continue
+		}
+		hasMovetext = true
+		lastField := movetextFields[len(movetextFields)-1]
+		if !isInComment && isPGNResult(lastField) {
+			break
+		}
+	}
+	if !hasStarted {
+		return nil, io.EOF
+	}
+
+	pgn := gameBuilder.String()[:contentLength]
+	record := &PGNRecord{
+		Index:  reader.gameCount,
+		Offset: gameOffset,
+		Length: int64(contentLength),
+		PGN:    pgn,
+	}
+	reader.gameCount++
+	tree, treeErr := ParsePGNTree(pgn)
+	if treeErr != nil {
+		record.Err = fmt.Errorf("could not read game %d at offset %d: %w", record.Index, record.Offset, treeErr)
+	} else {
+		record.Tree = tree
+	}
+	return record, nil
+}
+
+// readLine returns the next line including its line break, or io.EOF once the stream is exhausted
+func (reader *PGNReader) readLine() (string, error) {
+	if reader.pendingLine != "" {
+		line := reader.pendingLine
+		reader.pendingLine = ""
+		return line, nil
+	}
+	if reader.isEOF {
+		return "", io.EOF
+	}
+	line, err := reader.reader.ReadString('\n')
+	if err == io.EOF {
+		reader.isEOF = true
+		if line == "" {
+			return "", io.EOF
+		}
+	} else if err != nil {
+		return "", err
+	}
+	if reader.offset == 0 && strings.HasPrefix(line, "\ufeff") {
+		// the byte order mark still counts towards offsets, so it's only skipped over
+		reader.offset += int64(len("\ufeff"))
+		line = strings.TrimPrefix(line, "\ufeff")
+	}
+	return line, nil
+}
+
+// pgnMovetextOutsideComments strips brace and line comments from a line of movetext. It reports whether a brace
+// comment is still open at the end of the line, so the caller can carry that into the next line.
+func pgnMovetextOutsideComments(line string, isInComment bool) (string, bool) {
+	var movetextBuilder strings.Builder
+	for _, r := range line {
+		if isInComment {
+			if r == '}' {
+				isInComment = false
+			}
+			continue
+		}
+		if r == '{' {
+			isInComment = true
+			continue
+		}
+		if r == ';' {
+			break
+		}
+		movetextBuilder.WriteRune(r)
+	}
+	return movetextBuilder.String(), isInComment
+}
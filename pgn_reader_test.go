@@ -0,0 +1,74 @@
+package chess_test
+
+import (
+	"io"
+	"strings"
+
+	. "github.com/CameronHonis/chess"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const PGN_DATABASE = `[Event "First"]
+[Result "1-0"]
+
+1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# 1-0
+
+[Event "Broken"]
+[Result "*"]
+
+1. e4 e5 2. Ke3 *
+[Event "Third"]
+[Result "0-1"]
+
+1. f3 e5 {a comment
+[spanning lines]} 2. g4 Qh4# 0-1
+`
+
+var _ = Describe("PGNReader", func() {
+	Describe("::Next", func() {
+		var records []*PGNRecord
+		BeforeEach(func() {
+			records = make([]*PGNRecord, 0)
+			reader := NewPGNReader(strings.NewReader(PGN_DATABASE))
+			for {
+				record, err := reader.Next()
+				if err == io.EOF {
+					break
+				}
+				Expect(err).ToNot(HaveOccurred())
+				records = append(records, record)
+			}
+		})
+		It("yields every game in the stream", func() {
+			Expect(records).To(HaveLen(3))
+			for idx, record := range records {
+				Expect(record.Index).To(Equal(idx))
+			}
+		})
+		It("parses well formed games", func() {
+			Expect(records[0].Err).ToNot(HaveOccurred())
+			Expect(records[0].Tree.Result).To(Equal("1-0"))
+			Expect(records[0].Tree.Root.MainLine()).To(HaveLen(8))
+		})
+		It("reports malformed games without stopping", func() {
+			Expect(records[1].Tree).To(BeNil())
+			Expect(records[1].Err).To(HaveOccurred())
+			Expect(records[1].Err.Error()).To(ContainSubstring("Ke3"))
+			Expect(records[2].Err).ToNot(HaveOccurred())
+		})
+		It("does not split games on brackets inside comments", func() {
+			event, _ := records[2].Tree.GetTag("Event")
+			Expect(event).To(Equal("Third"))
+			Expect(records[2].Tree.Root.MainLine()).To(HaveLen(5))
+		})
+		It("exposes the byte span of every game", func() {
+			for _, record := range records {
+				span := PGN_DATABASE[record.Offset : record.Offset+record.Length]
+				Expect(span).To(Equal(record.PGN))
+				Expect(span).To(HavePrefix("[Event"))
+				Expect(span).To(MatchRegexp(`(1-0|0-1|\*)$`))
+			}
+		})
+	})
+})
@@ -0,0 +1,147 @@
+package chess_test
+
+import (
+	. "github.com/CameronHonis/chess"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const OPERA_GAME_PGN = `[Event "Paris"]
+[Site "Paris FRA"]
+[Date "1858.??.??"]
+[Round "?"]
+[White "Paul Morphy"]
+[Black "Duke Karl / Count Isouard"]
+[Result "1-0"]
+
+1. e4 e5 2. Nf3 d6 3. d4 Bg4 4. dxe5 Bxf3 5. Qxf3 dxe5 6. Bc4 Nf6 7. Qb3 Qe7
+8. Nc3 c6 9. Bg5 b5 10. Nxb5 cxb5 11. Bxb5+ Nbd7 12. O-O-O Rd8 13. Rxd7 Rxd7
+14. Rd1 Qe6 15. Bxd7+ Nxd7 16. Qb8+ Nxb8 17. Rd8# 1-0
+`
+
+var _ = Describe("PGN", func() {
+	Describe("#ParsePGN", func() {
+		When("the PGN is a complete game", func() {
+			var game *PGNGame
+			BeforeEach(func() {
+				var err error
+				game, err = ParsePGN(OPERA_GAME_PGN)
+				Expect(err).ToNot(HaveOccurred())
+			})
+			It("reads the tag pairs in order", func() {
+				Expect(game.Tags).To(HaveLen(7))
+				Expect(game.Tags[0]).To(Equal(&PGNTag{"Event", "Paris"}))
+				white, ok := game.GetTag("White")
+				Expect(ok).To(BeTrue())
+				Expect(white).To(Equal("Paul Morphy"))
+			})
+			It("replays every move", func() {
+				Expect(game.Moves).To(HaveLen(33))
+				Expect(game.Boards).To(HaveLen(34))
+				Expect(game.Boards[0].IsInitBoard()).To(BeTrue())
+				Expect(game.Moves[22].IsCastles()).To(BeTrue())
+			})
+			It("reaches the final position", func() {
+				Expect(game.FinalBoard().ToFEN()).To(Equal("1n1Rkb1r/p4ppp/4q3/4p1B1/4P3/8/PPP2PPP/2K5 b k - 1 17"))
+				Expect(game.FinalBoard().Result).To(Equal(BOARD_RESULT_WHITE_WINS_BY_CHECKMATE))
+			})
+			It("reads the result token", func() {
+				Expect(game.Result).To(Equal("1-0"))
+			})
+		})
+		When("the PGN starts from a FEN with black to move", func() {
+			It("replays the moves from the FEN position", func() {
+				pgn := `[SetUp "1"]
+[FEN "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"]
+
+1... e5 2. Nf3 Nc6 *`
+				game, err := ParsePGN(pgn)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(game.Moves).To(HaveLen(3))
+				Expect(game.Moves[0].Piece).To(Equal(BLACK_PAWN))
+				Expect(game.Result).To(Equal("*"))
+			})
+		})
+		When("the movetext contains comments, annotations and variations", func() {
+			It("only replays the main line", func() {
+				pgn := "1. e4 {best by test} e5!? (1... c5 2. Nf3) 2. Nf3 $1 ; line comment\nNc6 1/2-1/2"
+				game, err := ParsePGN(pgn)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(game.Moves).To(HaveLen(4))
+				Expect(game.FinalBoard().ToMiniFEN()).To(Equal("r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq -"))
+				Expect(game.Result).To(Equal("1/2-1/2"))
+			})
+		})
+		When("a move cannot be played", func() {
+			It("returns an error naming the ply and token", func() {
+				_, err := ParsePGN("1. e4 e5 2. Ke3 *")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Ke3"))
+				Expect(err.Error()).To(ContainSubstring("ply 3"))
+			})
+		})
+		When("a tag pair is malformed", func() {
+			It("returns an error", func() {
+				_, err := ParsePGN(`[Event Paris]` + "\n\n1. e4 *")
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("#PGNFromMoves", func() {
+		When("the game starts from the initial board", func() {
+			It("writes the seven tag roster with placeholders", func() {
+				board := GetInitBoard()
+				move, _ := MoveFromAlgebraic("e4", board)
+				pgn, err := PGNFromMoves(board, []*Move{move}, []*PGNTag{{"White", "Paul Morphy"}})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pgn).To(Equal(`[Event "?"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "Paul Morphy"]
+[Black "?"]
+[Result "*"]
+
+1. e4 *
+`))
+			})
+		})
+		When("the game starts from a FEN with black to move", func() {
+			It("numbers the first move as a black continuation", func() {
+				board, _ := BoardFromFEN("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 7")
+				blackMove, _ := MoveFromAlgebraic("c5", board)
+				whiteMove, _ := MoveFromAlgebraic("Nf3", GetBoardFromMove(board, blackMove))
+				pgn, err := PGNFromMoves(board, []*Move{blackMove, whiteMove}, []*PGNTag{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pgn).To(ContainSubstring("[FEN \"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 7\"]\n"))
+				Expect(pgn).To(ContainSubstring("[SetUp \"1\"]\n"))
+				Expect(pgn).To(HaveSuffix("\n\n7... c5 8. Nf3 *\n"))
+			})
+		})
+		When("the game ended on the board", func() {
+			It("derives the result from the final board", func() {
+				board, _ := BoardFromFEN("7k/5ppp/8/8/8/8/8/3RK3 w - - 0 1")
+				move, _ := MoveFromAlgebraic("Rd8", board)
+				pgn, err := PGNFromMoves(board, []*Move{move}, []*PGNTag{{"Result", "*"}})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pgn).To(ContainSubstring("[Result \"1-0\"]"))
+				Expect(pgn).To(HaveSuffix("1. Rd8# 1-0\n"))
+			})
+		})
+		When("a move is not legal", func() {
+			It("returns an error", func() {
+				board := GetInitBoard()
+				move := &Move{WHITE_PAWN, &Square{2, 5}, &Square{5, 5}, EMPTY, make([]*Square, 0), EMPTY}
+				_, err := PGNFromMoves(board, []*Move{move}, []*PGNTag{})
+				Expect(err).To(HaveOccurred())
+			})
+		})
+		It("wraps movetext at 80 columns", func() {
+			game, _ := ParsePGN(OPERA_GAME_PGN)
+			pgn, err := game.ToPGN()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pgn).To(Equal(OPERA_GAME_PGN))
+		})
+	})
+})
@@ -1,7 +1,5 @@
 package chess
 
-import "fmt"
-
 type Piece uint8
 
 const (
@@ -57,21 +55,5 @@ func (p Piece) IsKing() bool {
 }
 
 func (p Piece) ToAlgebraic() string {
-	switch p {
-	case WHITE_PAWN, BLACK_PAWN:
-		return "P"
-	case WHITE_KNIGHT, BLACK_KNIGHT:
-		return "N"
-	case WHITE_BISHOP, BLACK_BISHOP:
-		return "B"
-	case WHITE_ROOK, BLACK_ROOK:
-		return "R"
-	case WHITE_QUEEN, BLACK_QUEEN:
-		return "Q"
-	case WHITE_KING, BLACK_KING:
-		return "K"
-	default:
-		panic(fmt.Sprintf("cannot convert invalid piece %s to algebraic notation", p))
-	}
-	return ""
+	return p.ToAlgebraicWithOptions(nil)
 }
@@ -0,0 +1,225 @@
+package chess
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+)
+
+// ErrNotInBook is returned when the book has no move to play in the position
+var ErrNotInBook = errors.New("position not in book")
+
+// polyglotEntrySize is the size of a book entry on disk: the key, the move, the weight and the learn value, all
+// big-endian
+const polyglotEntrySize = 16
+
+type BookSelection string
+
+const (
+	// BOOK_SELECTION_BEST plays the move with the highest weight, the first of them in the book on a tie
+	BOOK_SELECTION_BEST BookSelection = "best"
+	// BOOK_SELECTION_WEIGHTED_RANDOM picks a move at random, each move as likely as its share of the weights
+	BOOK_SELECTION_WEIGHTED_RANDOM BookSelection = "weighted_random"
+	// BOOK_SELECTION_DETERMINISTIC spreads the picks over the moves by weight like BOOK_SELECTION_WEIGHTED_RANDOM,
+	// but draws from the position's key instead of a random number, so a position always gets the same move
+	BOOK_SELECTION_DETERMINISTIC BookSelection = "deterministic"
+)
+
+// PolyglotEntry is one record of a Polyglot book: a move from the position with the key, weighted by how much
+// the move should be played. The move packs the destination square in bits 0-5, the origin square in bits 6-11
+// and the promotion piece in bits 12-14 (1 knight to 4 queen). Learn is left to the tools that write the book.
+type PolyglotEntry struct {
+	Key    uint64
+	Move   uint16
+	Weight uint16
+	Learn  uint32
+}
+
+// BookMove is a book entry decoded into a move on the board it was looked up for
+type BookMove struct {
+	Move   *Move
+	Weight uint16
+	Learn  uint32
+}
+
+// PolyglotBook is an opening book in Polyglot's .bin format, held in memory
+type PolyglotBook struct {
+	entries []PolyglotEntry
+}
+
+// ReadPolyglotBook reads a whole book. Polyglot books are sorted by key; a book that isn't is sorted on reading,
+// keeping the order of each position's moves.
+func ReadPolyglotBook(reader io.Reader) (*PolyglotBook, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if len(data)%polyglotEntrySize != 0 {
+		return nil, fmt.Errorf("polyglot book of %d bytes is not made of %d byte entries", len(data), polyglotEntrySize)
+	}
+	entries := make([]PolyglotEntry, len(data)/polyglotEntrySize)
+	for idx := range entries {
+		entryData := data[idx*polyglotEntrySize:]
+		entries[idx] = PolyglotEntry{
+			Key:    binary.BigEndian.Uint64(entryData[0:8]),
+			Move:   binary.BigEndian.Uint16(entryData[8:10]),
+			Weight: binary.BigEndian.Uint16(entryData[10:12]),
+			Learn:  binary.BigEndian.Uint32(entryData[12:16]),
+		}
+	}
+	isByKey := func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	}
+	if !sort.SliceIsSorted(entries, isByKey) {
+		sort.SliceStable(entries, isByKey)
+	}
+	return &PolyglotBook{entries}, nil
+}
+
+// WritePolyglotBook writes the entries in Polyglot's .bin format. Readers look positions up by binary search, so
+// the entries must already be sorted by key.
+func WritePolyglotBook(writer io.Writer, entries []PolyglotEntry) error {
+	bufWriter := bufio.NewWriter(writer)
+	entryData := make([]byte, polyglotEntrySize)
+	for _, entry := range entries {
+		binary.BigEndian.PutUint64(entryData[0:8], entry.Key)
+		binary.BigEndian.PutUint16(entryData[8:10], entry.Move)
+		binary.BigEndian.PutUint16(entryData[10:12], entry.Weight)
+		binary.BigEndian.PutUint32(entryData[12:16], entry.Learn)
+		if _, err := bufWriter.Write(entryData); err != nil {
+			return err
+		}
+	}
+	return bufWriter.Flush()
+}
+
+// Entries returns the book's entries for the key, in book order
+func (book *PolyglotBook) Entries(key uint64) []PolyglotEntry {
+	start := sort.Search(len(book.entries), func(idx int) bool {
+		return book.entries[idx].Key >= key
+	})
+	end := start
+	for end < len(book.entries) && book.entries[end].Key == key {
+		end++
+	}
+	return book.entries[start:end]
+}
+
+// Moves looks the board up in the book and decodes the entries into the board's legal moves, in book order.
+// Entries that don't decode into a legal move, as when another position shares the key, are left out.
+func (book *PolyglotBook) Moves(board *Board) ([]*BookMove, error) {
+	entries := book.Entries(board.PolyglotKey())
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	legalMoves, err := GetLegalMoves(board)
+	if err != nil {
+		return nil, err
+	}
+	bookMoves := make([]*BookMove, 0, len(entries))
+	for _, entry := range entries {
+		if move := decodePolyglotMove(entry.Move, board, legalMoves); move != nil {
+			bookMoves = append(bookMoves, &BookMove{move, entry.Weight, entry.Learn})
+		}
+	}
+	return bookMoves, nil
+}
+
+// PickMove chooses one of the board's book moves. As in Polyglot, moves with no weight are never played. rng is
+// only drawn from for BOOK_SELECTION_WEIGHTED_RANDOM, where nil means math/rand's shared source. It returns
+// ErrNotInBook when the book has no move to play.
+func (book *PolyglotBook) PickMove(board *Board, selection BookSelection, rng *rand.Rand) (*Move, error) {
+	bookMoves, err := book.Moves(board)
+	if err != nil {
+		return nil, err
+	}
+	var totalWeight uint64
+	var bestMove *BookMove
+	for _, bookMove := range bookMoves {
+		totalWeight += uint64(bookMove.Weight)
+		if bestMove == nil || bookMove.Weight > bestMove.Weight {
+			bestMove = bookMove
+		}
+	}
+	if totalWeight == 0 {
+		return nil, ErrNotInBook
+	}
+
+	var pick uint64
+	switch selection {
+	case BOOK_SELECTION_BEST:
+		return bestMove.Move, nil
+	case BOOK_SELECTION_WEIGHTED_RANDOM:
+		if rng != nil {
+			pick = uint64(rng.Int63n(int64(totalWeight)))
+		} else {
+			pick = uint64(rand.Int63n(int64(totalWeight)))
+		}
+	case BOOK_SELECTION_DETERMINISTIC:
+		pick = board.PolyglotKey() % totalWeight
+	default:
+		return nil, fmt.Errorf("unknown book selection %q", selection)
+	}
+	for _, bookMove := range bookMoves {
+		if pick < uint64(bookMove.Weight) {
+			return bookMove.Move, nil
+		}
+		pick -= uint64(bookMove.Weight)
+	}
+	return bestMove.Move, nil
+}
+
+// decodePolyglotMove finds the legal move an entry's move stands for. Polyglot writes castling as the king taking
+// its own rook, which is how Chess960 boards take castling moves already, but on a standard board the king lands
+// two squares over.
+func decodePolyglotMove(encodedMove uint16, board *Board, legalMoves []*Move) *Move {
+	endSquare := squareFromIndex(int(encodedMove & 63))
+	startSquare := squareFromIndex(int(encodedMove>>6) & 63)
+	var upgradePiece Piece
+	if promotion := Piece(encodedMove>>12) & 7; promotion != 0 {
+		upgradePiece = WHITE_PAWN + promotion
+		if !board.IsWhiteTurn {
+			upgradePiece = BLACK_PAWN + promotion
+		}
+	}
+	if !board.IsChess960 && board.GetPieceOnSquare(startSquare).IsKing() &&
+		startSquare.File == 5 && endSquare.Rank == startSquare.Rank {
+		if endSquare.File == 8 {
+			endSquare = &Square{endSquare.Rank, 7}
+		} else if endSquare.File == 1 {
+			endSquare = &Square{endSquare.Rank, 3}
+		}
+	}
+	for _, move := range legalMoves {
+		if move.StartSquare.Equal(startSquare) && move.EndSquare.Equal(endSquare) &&
+			move.PawnUpgradedTo == upgradePiece {
+			return move
+		}
+	}
+	return nil
+}
+
+// encodePolyglotMove packs a move made on the board the way book entries hold it, see decodePolyglotMove
+func encodePolyglotMove(move *Move, board *Board) uint16 {
+	endSquare := move.EndSquare
+	if !board.IsChess960 && move.IsCastles() {
+		rookFile := uint8(8)
+		if endSquare.File == 3 {
+			rookFile = 1
+		}
+		endSquare = &Square{endSquare.Rank, rookFile}
+	}
+	encodedMove := uint16(squareIndex(move.StartSquare))<<6 | uint16(squareIndex(endSquare))
+	if move.PawnUpgradedTo != EMPTY {
+		promotion := move.PawnUpgradedTo - WHITE_PAWN
+		if !move.PawnUpgradedTo.IsWhite() {
+			promotion = move.PawnUpgradedTo - BLACK_PAWN
+		}
+		encodedMove |= uint16(promotion) << 12
+	}
+	return encodedMove
+}
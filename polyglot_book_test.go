@@ -0,0 +1,107 @@
+package chess_test
+
+import (
+	"math/rand"
+	"os"
+	"strings"
+
+	. "github.com/CameronHonis/chess"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// test_book.bin holds e4 (10), d4 (5), Nf3 (0) and the illegal a1a8 from the initial board, c5 (3) and e5 (2)
+// after 1. e4, castling kingside in the Italian and b8=N (1) and b8=Q (2) in a pawn ending
+func readTestBook() *PolyglotBook {
+	file, err := os.Open("./test_book.bin")
+	Expect(err).ToNot(HaveOccurred())
+	defer file.Close()
+	book, err := ReadPolyglotBook(file)
+	Expect(err).ToNot(HaveOccurred())
+	return book
+}
+
+func bookMoveNotations(board *Board, bookMoves []*BookMove) []string {
+	notations := make([]string, 0, len(bookMoves))
+	for _, bookMove := range bookMoves {
+		notations = append(notations, bookMove.Move.ToAlgebraic(board))
+	}
+	return notations
+}
+
+var _ = Describe("PolyglotBook", func() {
+	var book *PolyglotBook
+	BeforeEach(func() {
+		book = readTestBook()
+	})
+
+	Describe("::Moves", func() {
+		It("decodes the legal moves in book order, leaving out the others", func() {
+			board := GetInitBoard()
+			bookMoves, err := book.Moves(board)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(bookMoveNotations(board, bookMoves)).To(Equal([]string{"e4", "d4", "Nf3"}))
+			Expect(bookMoves[0].Weight).To(Equal(uint16(10)))
+		})
+		It("decodes castling into the king's move on a standard board", func() {
+			board, _ := BoardFromFEN("r1bqk1nr/pppp1ppp/2n5/2b1p3/2B1P3/5N2/PPPP1PPP/RNBQK2R w KQkq - 4 4")
+			bookMoves, err := book.Moves(board)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(bookMoves).To(HaveLen(1))
+			Expect(bookMoves[0].Move.EndSquare).To(Equal(&Square{1, 7}))
+			Expect(bookMoves[0].Move.ToAlgebraic(board)).To(Equal("O-O"))
+		})
+		It("decodes promotions", func() {
+			board, _ := BoardFromFEN("8/1P6/8/8/8/8/5k2/K7 w - - 0 1")
+			bookMoves, err := book.Moves(board)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(bookMoveNotations(board, bookMoves)).To(Equal([]string{"b8=N", "b8=Q"}))
+		})
+		It("returns no moves for a position not in the book", func() {
+			board, _ := BoardFromFEN("rnbqkbnr/pppppppp/8/8/3P4/8/PPP1PPPP/RNBQKBNR b KQkq - 0 1")
+			Expect(book.Moves(board)).To(BeEmpty())
+		})
+	})
+
+	Describe("::PickMove", func() {
+		It("plays the move with the highest weight", func() {
+			board := GetInitBoard()
+			move, err := book.PickMove(board, BOOK_SELECTION_BEST, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(move.ToAlgebraic(board)).To(Equal("e4"))
+		})
+		It("picks the same move for a position every time", func() {
+			board, _ := BoardFromFEN("8/1P6/8/8/8/8/5k2/K7 w - - 0 1")
+			move, err := book.PickMove(board, BOOK_SELECTION_DETERMINISTIC, nil)
+			Expect(err).ToNot(HaveOccurred())
+			for i := 0; i < 5; i++ {
+				Expect(book.PickMove(board, BOOK_SELECTION_DETERMINISTIC, nil)).To(Equal(move))
+			}
+		})
+		It("picks moves at random by weight, never a move without weight", func() {
+			board := GetInitBoard()
+			rng := rand.New(rand.NewSource(3))
+			countByNotation := make(map[string]int)
+			for i := 0; i < 300; i++ {
+				move, err := book.PickMove(board, BOOK_SELECTION_WEIGHTED_RANDOM, rng)
+				Expect(err).ToNot(HaveOccurred())
+				countByNotation[move.ToAlgebraic(board)]++
+			}
+			Expect(countByNotation).To(HaveLen(2))
+			Expect(countByNotation["e4"]).To(BeNumerically(">", countByNotation["d4"]))
+			Expect(countByNotation["d4"]).To(BeNumerically(">", 50))
+		})
+		It("returns ErrNotInBook for a position not in the book", func() {
+			board, _ := BoardFromFEN("rnbqkbnr/pppppppp/8/8/3P4/8/PPP1PPPP/RNBQKBNR b KQkq - 0 1")
+			_, err := book.PickMove(board, BOOK_SELECTION_BEST, nil)
+			Expect(err).To(MatchError(ErrNotInBook))
+		})
+	})
+
+	Describe("#ReadPolyglotBook", func() {
+		It("rejects data that isn't made of whole entries", func() {
+			_, err := ReadPolyglotBook(strings.NewReader("not a book"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,333 @@
+package chess
+
+import "math"
+
+// Position is a Board held in bitboards, one per piece, for fast move generation. It carries what decides the
+// legal moves and the move counters, but not the repetition history or the result; convert with NewPosition and
+// ToBoard.
+type Position struct {
+	// pieceBitboards is indexed by Piece, the EMPTY entry is unused
+	pieceBitboards [BLACK_KING + 1]Bitboard
+	whitePieces    Bitboard
+	blackPieces    Bitboard
+	// mailbox holds the piece on each square, for looking up what stands on a square of a bitboard
+	mailbox     [64]Piece
+	isWhiteTurn bool
+	// enPassantIdx is the square index of the en passant square, or -1
+	enPassantIdx int
+	// castle rights and rook squares are indexed by castleIdx. The rook squares are kept after a right is lost,
+	// since a move landing on one still clears the right, just like on the Board.
+	castleRights    [4]bool
+	castleRookIdxs  [4]int
+	castleRookFiles [4]uint8
+	isChess960      bool
+
+	halfMoveClockCount uint8
+	fullMoveCount      uint16
+	// key is the Zobrist key, see Key
+	key uint64
+}
+
+func castleIdx(isWhite bool, isKingside bool) int {
+	idx := 0
+	if !isWhite {
+		idx += 2
+	}
+	if !isKingside {
+		idx++
+	}
+	return idx
+}
+
+func NewPosition(board *Board) *Position {
+	position := &Position{
+		isWhiteTurn:        board.IsWhiteTurn,
+		enPassantIdx:       -1,
+		isChess960:         board.IsChess960,
+		halfMoveClockCount: board.HalfMoveClockCount,
+		fullMoveCount:      board.FullMoveCount,
+		castleRights: [4]bool{
+			board.CanWhiteCastleKingside, board.CanWhiteCastleQueenside,
+			board.CanBlackCastleKingside, board.CanBlackCastleQueenside,
+		},
+		castleRookFiles: [4]uint8{
+			board.WhiteKingsideRookFile, board.WhiteQueensideRookFile,
+			board.BlackKingsideRookFile, board.BlackQueensideRookFile,
+		},
+	}
+	for rank := uint8(1); rank < 9; rank++ {
+		for file := uint8(1); file < 9; file++ {
+			square := &Square{rank, file}
+			if piece := board.GetPieceOnSquare(square); piece != EMPTY {
+				position.putPiece(piece, squareIndex(square))
+			}
+		}
+	}
+	if board.OptEnPassantSquare != nil {
+		position.enPassantIdx = squareIndex(board.OptEnPassantSquare)
+	}
+	for _, isWhite := range []bool{true, false} {
+		for _, isKingside := range []bool{true, false} {
+			position.castleRookIdxs[castleIdx(isWhite, isKingside)] = squareIndex(board.CastleRookSquare(isWhite, isKingside))
+		}
+	}
+	position.key = position.computeKey()
+	return position
+}
+
+// ToBoard converts the position back to a Board, whose repetition history starts at this position
+func (position *Position) ToBoard() *Board {
+	boardBuilder := NewBoardBuilder().
+		WithIsWhiteTurn(position.isWhiteTurn).
+		WithIsChess960(position.isChess960).
+		WithHalfMoveClockCount(position.halfMoveClockCount).
+		WithFullMoveCount(position.fullMoveCount).
+		WithCanWhiteCastleKingside(position.castleRights[castleIdx(true, true)]).
+		WithCanWhiteCastleQueenside(position.castleRights[castleIdx(true, false)]).
+		WithCanBlackCastleKingside(position.castleRights[castleIdx(false, true)]).
+		WithCanBlackCastleQueenside(position.castleRights[castleIdx(false, false)])
+	var pieces [8][8]Piece
+	for idx, piece := range position.mailbox {
+		pieces[idx/8][idx%8] = piece
+	}
+	boardBuilder.WithPieces(pieces)
+	for _, isWhite := range []bool{true, false} {
+		for _, isKingside := range []bool{true, false} {
+			boardBuilder.WithCastleRookFile(isWhite, isKingside, position.castleRookFiles[castleIdx(isWhite, isKingside)])
+		}
+	}
+	if position.enPassantIdx >= 0 {
+		boardBuilder.WithEnPassantSquare(squareFromIndex(position.enPassantIdx))
+	}
+	return finishBoard(boardBuilder)
+}
+
+func (position *Position) IsWhiteTurn() bool {
+	return position.isWhiteTurn
+}
+
+func (position *Position) GetPieceOnSquare(square *Square) Piece {
+	return position.mailbox[squareIndex(square)]
+}
+
+// PieceBitboard returns the squares the piece stands on
+func (position *Position) PieceBitboard(piece Piece) Bitboard {
+	return position.pieceBitboards[piece]
+}
+
+// Occupancy returns the squares the pieces of one side stand on
+func (position *Position) Occupancy(isWhite bool) Bitboard {
+	if isWhite {
+		return position.whitePieces
+	}
+	return position.blackPieces
+}
+
+func (position *Position) putPiece(piece Piece, idx int) {
+	squareBitboard := Bitboard(1) << idx
+	position.mailbox[idx] = piece
+	position.pieceBitboards[piece] |= squareBitboard
+	position.key ^= zobrist.pieces[piece][idx]
+	if piece.IsWhite() {
+		position.whitePieces |= squareBitboard
+	} else {
+		position.blackPieces |= squareBitboard
+	}
+}
+
+func (position *Position) removePiece(idx int) Piece {
+	piece := position.mailbox[idx]
+	if piece == EMPTY {
+		return EMPTY
+	}
+	squareBitboard := Bitboard(1) << idx
+	position.mailbox[idx] = EMPTY
+	position.pieceBitboards[piece] &^= squareBitboard
+	position.key ^= zobrist.pieces[piece][idx]
+	position.whitePieces &^= squareBitboard
+	position.blackPieces &^= squareBitboard
+	return piece
+}
+
+// kingIdx returns the square index of the side's king, or -1 on a board without one
+func (position *Position) kingIdx(isWhite bool) int {
+	king := WHITE_KING
+	if !isWhite {
+		king = BLACK_KING
+	}
+	if position.pieceBitboards[king] == 0 {
+		return -1
+	}
+	return position.pieceBitboards[king].lowestIndex()
+}
+
+// isCastleMove recognizes castling like Move.IsCastlesOn, from the square indexes of a king move
+func (position *Position) isCastleMove(fromIdx int, toIdx int) bool {
+	piece := position.mailbox[fromIdx]
+	if !piece.IsKing() {
+		return false
+	}
+	if position.isChess960 {
+		landPiece := position.mailbox[toIdx]
+		return landPiece.IsRook() && landPiece.IsWhite() == piece.IsWhite()
+	}
+	return fromIdx%8 == 4 && fromIdx/8 == toIdx/8 && (toIdx%8 == 2 || toIdx%8 == 6)
+}
+
+// MoveUndo records what MakeMove changed, for UnmakeMove to put back
+type MoveUndo struct {
+	fromIdx            int
+	toIdx              int
+	movingPiece        Piece
+	capturedPiece      Piece
+	capturedIdx        int
+	isCastle           bool
+	castleRights       [4]bool
+	enPassantIdx       int
+	halfMoveClockCount uint8
+	key                uint64
+}
+
+// MakeMove plays a legal move in place, updating the position the way GetBoardFromMove updates a Board. Unlike
+// GetBoardFromMove it allocates nothing, so a search can walk the game tree with AppendLegalMoves, MakeMove and
+// UnmakeMove.
+func (position *Position) MakeMove(move PositionMove) MoveUndo {
+	fromIdx, toIdx, upgradePiece := move.FromIdx, move.ToIdx, move.UpgradePiece
+	movingPiece := position.mailbox[fromIdx]
+	undo := MoveUndo{
+		fromIdx:            fromIdx,
+		toIdx:              toIdx,
+		movingPiece:        movingPiece,
+		capturedIdx:        toIdx,
+		castleRights:       position.castleRights,
+		enPassantIdx:       position.enPassantIdx,
+		halfMoveClockCount: position.halfMoveClockCount,
+		key:                position.key,
+	}
+	position.key ^= position.enPassantKey()
+	if position.isCastleMove(fromIdx, toIdx) {
+		undo.isCastle = true
+		isWhite := movingPiece.IsWhite()
+		isKingside := toIdx%8 > fromIdx%8
+		kingLandIdx, rookLandIdx := castleLandIdxs(isWhite, isKingside)
+		position.removePiece(fromIdx)
+		rookPiece := position.removePiece(position.castleRookIdxs[castleIdx(isWhite, isKingside)])
+		position.putPiece(movingPiece, kingLandIdx)
+		position.putPiece(rookPiece, rookLandIdx)
+	} else {
+		position.removePiece(fromIdx)
+		undo.capturedPiece = position.removePiece(toIdx)
+		if movingPiece.IsPawn() && toIdx == position.enPassantIdx {
+			undo.capturedIdx = fromIdx/8*8 + toIdx%8
+			undo.capturedPiece = position.removePiece(undo.capturedIdx)
+		}
+		if upgradePiece != EMPTY {
+			position.putPiece(upgradePiece, toIdx)
+		} else {
+			position.putPiece(movingPiece, toIdx)
+		}
+	}
+
+	position.enPassantIdx = -1
+	if movingPiece.IsPawn() && (toIdx-fromIdx == 16 || fromIdx-toIdx == 16) {
+		position.enPassantIdx = (fromIdx + toIdx) / 2
+	}
+	position.updateCastleRights(movingPiece, fromIdx, toIdx)
+	for idx, canCastle := range position.castleRights {
+		if canCastle != undo.castleRights[idx] {
+			position.key ^= zobrist.castleRights[idx]
+		}
+	}
+	if undo.capturedPiece != EMPTY || movingPiece.IsPawn() || position.castleRights != undo.castleRights {
+		position.halfMoveClockCount = 0
+	} else if position.halfMoveClockCount < math.MaxUint8 {
+		position.halfMoveClockCount++
+	}
+	if !position.isWhiteTurn {
+		position.fullMoveCount++
+	}
+	position.isWhiteTurn = !position.isWhiteTurn
+	position.key ^= zobrist.isBlackTurn
+	position.key ^= position.enPassantKey()
+	return undo
+}
+
+// UnmakeMove takes back the move MakeMove returned the undo for, which must be the last move made
+func (position *Position) UnmakeMove(undo MoveUndo) {
+	position.isWhiteTurn = !position.isWhiteTurn
+	if !position.isWhiteTurn {
+		position.fullMoveCount--
+	}
+	if undo.isCastle {
+		isWhite := undo.movingPiece.IsWhite()
+		isKingside := undo.toIdx%8 > undo.fromIdx%8
+		kingLandIdx, rookLandIdx := castleLandIdxs(isWhite, isKingside)
+		position.removePiece(kingLandIdx)
+		rookPiece := position.removePiece(rookLandIdx)
+		position.putPiece(undo.movingPiece, undo.fromIdx)
+		position.putPiece(rookPiece, position.castleRookIdxs[castleIdx(isWhite, isKingside)])
+	} else {
+		position.removePiece(undo.toIdx)
+		position.putPiece(undo.movingPiece, undo.fromIdx)
+		if undo.capturedPiece != EMPTY {
+			position.putPiece(undo.capturedPiece, undo.capturedIdx)
+		}
+	}
+	position.castleRights = undo.castleRights
+	position.enPassantIdx = undo.enPassantIdx
+	position.halfMoveClockCount = undo.halfMoveClockCount
+	position.key = undo.key
+}
+
+// castleLandIdxs returns the square indexes castleLandSquares gives
+func castleLandIdxs(isWhite bool, isKingside bool) (int, int) {
+	rankOffset := int(backRank(isWhite)-1) * 8
+	if isKingside {
+		return rankOffset + 6, rankOffset + 5
+	}
+	return rankOffset + 2, rankOffset + 3
+}
+
+// updateCastleRights mirrors UpdateCastleRights
+func (position *Position) updateCastleRights(movingPiece Piece, fromIdx int, toIdx int) {
+	for _, idx := range []int{castleIdx(false, false), castleIdx(false, true), castleIdx(true, false), castleIdx(true, true)} {
+		if toIdx == position.castleRookIdxs[idx] {
+			position.castleRights[idx] = false
+			break
+		}
+	}
+	if movingPiece.IsRook() {
+		for _, idx := range []int{castleIdx(true, false), castleIdx(true, true), castleIdx(false, false), castleIdx(false, true)} {
+			if fromIdx == position.castleRookIdxs[idx] {
+				position.castleRights[idx] = false
+				break
+			}
+		}
+	} else if movingPiece.IsKing() {
+		isWhite := movingPiece.IsWhite()
+		position.castleRights[castleIdx(isWhite, true)] = false
+		position.castleRights[castleIdx(isWhite, false)] = false
+	}
+}
+
+// Perft counts the move sequences of the given length from the position, the standard check of a move generator.
+// It walks the tree with MakeMove and UnmakeMove, leaving the position as it was.
+func (position *Position) Perft(depth int) uint64 {
+	if depth == 0 {
+		return 1
+	}
+	var nodeCount uint64
+	if depth == 1 {
+		position.generateMoves(func(move PositionMove) bool {
+			nodeCount++
+			return true
+		})
+		return nodeCount
+	}
+	for _, move := range position.AppendLegalMoves(make([]PositionMove, 0, 64)) {
+		undo := position.MakeMove(move)
+		nodeCount += position.Perft(depth - 1)
+		position.UnmakeMove(undo)
+	}
+	return nodeCount
+}
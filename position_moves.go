@@ -0,0 +1,382 @@
+package chess
+
+// LegalMoves returns the same moves as GetLegalMoves, in the same order: by origin square from a1 to h8, then in
+// the order the per-piece generators like GetLegalMovesForKnight produce them
+func (position *Position) LegalMoves() []*Move {
+	moves := make([]*Move, 0, 48)
+	position.generateMoves(func(move PositionMove) bool {
+		moves = append(moves, move.ToMove(position))
+		return true
+	})
+	return moves
+}
+
+func (position *Position) HasLegalMove() bool {
+	hasMove := false
+	position.generateMoves(func(move PositionMove) bool {
+		hasMove = true
+		return false
+	})
+	return hasMove
+}
+
+// PositionMove is a move as the Position generates and plays it. It is a small value holding square indexes,
+// (rank-1)*8 + (file-1) like the bitboards, so generating and playing moves allocates nothing.
+type PositionMove struct {
+	Piece         Piece
+	FromIdx       int
+	ToIdx         int
+	CapturedPiece Piece
+	UpgradePiece  Piece
+}
+
+// NewPositionMove converts a Move, e.g. one read from notation, for playing with Position.MakeMove
+func NewPositionMove(move *Move) PositionMove {
+	return PositionMove{move.Piece, squareIndex(move.StartSquare), squareIndex(move.EndSquare), move.CapturedPiece,
+		move.PawnUpgradedTo}
+}
+
+// ToMove converts the move of the position to a Move, with the squares it checks the enemy king from. Like
+// GetLegalMovesForKing, king moves leave them empty.
+func (move PositionMove) ToMove(position *Position) *Move {
+	fullMove := &Move{move.Piece, squareFromIndex(move.FromIdx), squareFromIndex(move.ToIdx), move.CapturedPiece,
+		make([]*Square, 0), move.UpgradePiece}
+	if !move.Piece.IsKing() {
+		fullMove.KingCheckingSquares = position.checkingSquaresAfter(fullMove)
+	}
+	return fullMove
+}
+
+// AppendLegalMoves appends the legal moves to moves in the order of LegalMoves. Reusing the slice, e.g. one per
+// ply of a search, lets a search generate moves without allocating.
+func (position *Position) AppendLegalMoves(moves []PositionMove) []PositionMove {
+	position.generateMoves(func(move PositionMove) bool {
+		moves = append(moves, move)
+		return true
+	})
+	return moves
+}
+
+// generateMoves passes the legal moves of the side to move to visit until visit returns false
+func (position *Position) generateMoves(visit func(move PositionMove) bool) {
+	tables := getAttackTables()
+	isWhite := position.isWhiteTurn
+	own, enemy := position.Occupancy(isWhite), position.Occupancy(!isWhite)
+	gen := moveGenerator{
+		position:  position,
+		tables:    tables,
+		visit:     visit,
+		occupancy: own | enemy,
+		enemy:     enemy,
+		kingIdx:   position.kingIdx(isWhite),
+	}
+	for remaining := own; remaining != 0; remaining &= remaining - 1 {
+		idx := remaining.lowestIndex()
+		piece := position.mailbox[idx]
+		var isDone bool
+		switch {
+		case piece.IsPawn():
+			isDone = gen.pawnMoves(idx, piece)
+		case piece.IsKnight():
+			isDone = gen.targetMovesByRank(idx, piece, tables.knight[idx]&^own)
+		case piece.IsBishop():
+			isDone = gen.slidingMoves(idx, piece, tables.bishopAttacks(idx, gen.occupancy)&^own,
+				[]direction{dirNorthEast, dirSouthEast, dirNorthWest, dirSouthWest})
+		case piece.IsRook():
+			isDone = gen.slidingMoves(idx, piece, tables.rookAttacks(idx, gen.occupancy)&^own, straightDirections)
+		case piece.IsQueen():
+			attacks := tables.rookAttacks(idx, gen.occupancy) | tables.bishopAttacks(idx, gen.occupancy)
+			isDone = gen.slidingMoves(idx, piece, attacks&^own,
+				[]direction{dirNorth, dirSouth, dirEast, dirWest, dirNorthEast, dirNorthWest, dirSouthEast, dirSouthWest})
+		case piece.IsKing():
+			isDone = gen.kingMoves(idx, piece, tables.king[idx]&^own)
+		}
+		if isDone {
+			return
+		}
+	}
+}
+
+// moveGenerator holds what the generation of one position's moves shares between its pieces. Its methods return
+// true once visit asks to stop.
+type moveGenerator struct {
+	position  *Position
+	tables    *attackTables
+	visit     func(move PositionMove) bool
+	occupancy Bitboard
+	enemy     Bitboard
+	kingIdx   int
+}
+
+func (gen *moveGenerator) pawnMoves(idx int, piece Piece) bool {
+	isWhite := piece.IsWhite()
+	forward, startRank, promotionRank := 8, 1, 6
+	upgradePieces := []Piece{WHITE_KNIGHT, WHITE_BISHOP, WHITE_ROOK, WHITE_QUEEN}
+	if !isWhite {
+		forward, startRank, promotionRank = -8, 6, 1
+		upgradePieces = []Piece{BLACK_KNIGHT, BLACK_BISHOP, BLACK_ROOK, BLACK_QUEEN}
+	}
+	rank, file := idx/8, idx%8
+	frontIdx := idx + forward
+	if frontIdx < 0 || frontIdx > 63 {
+		return false
+	}
+	if gen.position.mailbox[frontIdx] == EMPTY {
+		if frontIdx/8 == 7 || frontIdx/8 == 0 {
+			for _, upgradePiece := range upgradePieces {
+				if gen.tryMove(piece, idx, frontIdx, EMPTY, -1, upgradePiece) {
+					return true
+				}
+			}
+		} else if gen.tryMove(piece, idx, frontIdx, EMPTY, -1, EMPTY) {
+			return true
+		}
+		twoFrontIdx := frontIdx + forward
+		if rank == startRank && gen.position.mailbox[twoFrontIdx] == EMPTY {
+			if gen.tryMove(piece, idx, twoFrontIdx, EMPTY, -1, EMPTY) {
+				return true
+			}
+		}
+	}
+	for _, fileStep := range []int{-1, 1} {
+		if file+fileStep < 0 || file+fileStep > 7 {
+			continue
+		}
+		captureIdx := frontIdx + fileStep
+		capturedIdx := captureIdx
+		if captureIdx == gen.position.enPassantIdx {
+			capturedIdx = idx + fileStep
+		}
+		capturedPiece := gen.position.mailbox[capturedIdx]
+		if capturedPiece == EMPTY || capturedPiece.IsWhite() == isWhite {
+			continue
+		}
+		if rank == promotionRank {
+			for _, upgradePiece := range upgradePieces {
+				if gen.tryMove(piece, idx, captureIdx, capturedPiece, capturedIdx, upgradePiece) {
+					return true
+				}
+			}
+		} else if gen.tryMove(piece, idx, captureIdx, capturedPiece, capturedIdx, EMPTY) {
+			return true
+		}
+	}
+	return false
+}
+
+// targetMovesByRank plays the piece to each target square from the eighth rank down, and along each rank from
+// the a-file, which is the order the knight generator lists its squares in
+func (gen *moveGenerator) targetMovesByRank(idx int, piece Piece, targets Bitboard) bool {
+	for rank := 7; rank >= 0; rank-- {
+		for rankTargets := (targets >> (rank * 8)) & 0xff; rankTargets != 0; rankTargets &= rankTargets - 1 {
+			toIdx := rank*8 + rankTargets.lowestIndex()
+			if gen.tryMove(piece, idx, toIdx, gen.position.mailbox[toIdx], toIdx, EMPTY) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// slidingMoves plays the piece along each ray in turn, nearest square first
+func (gen *moveGenerator) slidingMoves(idx int, piece Piece, targets Bitboard, dirs []direction) bool {
+	for _, dir := range dirs {
+		rayTargets := targets & gen.tables.rays[dir][idx]
+		for rayTargets != 0 {
+			var toIdx int
+			if dir.isIncreasing() {
+				toIdx = rayTargets.lowestIndex()
+			} else {
+				toIdx = rayTargets.highestIndex()
+			}
+			rayTargets &^= Bitboard(1) << toIdx
+			if gen.tryMove(piece, idx, toIdx, gen.position.mailbox[toIdx], toIdx, EMPTY) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// tryMove visits the move unless it leaves the mover's king attacked. The enemy king is left out of the attackers,
+// since only a king move can walk into it.
+func (gen *moveGenerator) tryMove(piece Piece, fromIdx int, toIdx int, capturedPiece Piece, capturedIdx int,
+	upgradePiece Piece) bool {
+	if gen.kingIdx >= 0 {
+		occupancy := gen.occupancy &^ (Bitboard(1) << fromIdx)
+		attackers := gen.enemy
+		if capturedIdx >= 0 {
+			occupancy &^= Bitboard(1) << capturedIdx
+			attackers &^= Bitboard(1) << capturedIdx
+		}
+		occupancy |= Bitboard(1) << toIdx
+		if gen.position.isAttacked(gen.kingIdx, occupancy, attackers, false) {
+			return false
+		}
+	}
+	return !gen.visit(PositionMove{piece, fromIdx, toIdx, capturedPiece, upgradePiece})
+}
+
+func (gen *moveGenerator) kingMoves(idx int, piece Piece, targets Bitboard) bool {
+	for rank := 7; rank >= 0; rank-- {
+		for rankTargets := (targets >> (rank * 8)) & 0xff; rankTargets != 0; rankTargets &= rankTargets - 1 {
+			toIdx := rank*8 + rankTargets.lowestIndex()
+			toBitboard := Bitboard(1) << toIdx
+			if gen.position.isAttacked(toIdx, gen.occupancy&^(Bitboard(1)<<idx), gen.enemy&^toBitboard, true) {
+				continue
+			}
+			if !gen.visit(PositionMove{piece, idx, toIdx, gen.position.mailbox[toIdx], EMPTY}) {
+				return true
+			}
+		}
+	}
+	for _, isKingside := range []bool{true, false} {
+		if move, canCastle := gen.castleMove(idx, piece, isKingside); canCastle && !gen.visit(move) {
+			return true
+		}
+	}
+	return false
+}
+
+// castleMove returns the castling move and whether it is legal by the rules canCastle checks
+func (gen *moveGenerator) castleMove(kingIdx int, piece Piece, isKingside bool) (PositionMove, bool) {
+	isWhite := piece.IsWhite()
+	position := gen.position
+	idx := castleIdx(isWhite, isKingside)
+	if !position.castleRights[idx] {
+		return PositionMove{}, false
+	}
+	rookIdx := position.castleRookIdxs[idx]
+	rookPiece := position.mailbox[rookIdx]
+	if kingIdx/8 != rookIdx/8 || !rookPiece.IsRook() || rookPiece.IsWhite() != isWhite {
+		return PositionMove{}, false
+	}
+	if (rookIdx > kingIdx) != isKingside {
+		return PositionMove{}, false
+	}
+	kingLandFile, rookLandFile := 2, 3
+	if isKingside {
+		kingLandFile, rookLandFile = 6, 5
+	}
+	rankOffset := kingIdx / 8 * 8
+	minFile, maxFile := kingIdx%8, kingIdx%8
+	for _, file := range []int{rookIdx % 8, kingLandFile, rookLandFile} {
+		if file < minFile {
+			minFile = file
+		}
+		if file > maxFile {
+			maxFile = file
+		}
+	}
+	occupancy := gen.occupancy &^ (Bitboard(1) << kingIdx) &^ (Bitboard(1) << rookIdx)
+	for file := minFile; file <= maxFile; file++ {
+		if occupancy&(Bitboard(1)<<(rankOffset+file)) != 0 {
+			return PositionMove{}, false
+		}
+	}
+	for file := kingIdx % 8; ; {
+		if position.isAttacked(rankOffset+file, occupancy, gen.enemy, true) {
+			return PositionMove{}, false
+		}
+		if file == kingLandFile {
+			break
+		}
+		if file < kingLandFile {
+			file++
+		} else {
+			file--
+		}
+	}
+	endIdx := rankOffset + kingLandFile
+	if position.isChess960 {
+		endIdx = rookIdx
+	}
+	return PositionMove{piece, kingIdx, endIdx, EMPTY, EMPTY}, true
+}
+
+// isAttacked reports whether any of the attackers, which are pieces of the side not to move, attack the square
+// with the given pieces on the board
+func (position *Position) isAttacked(idx int, occupancy Bitboard, attackers Bitboard, isKingIncluded bool) bool {
+	tables := getAttackTables()
+	knight, pawn, bishop, rook, queen, king := BLACK_KNIGHT, BLACK_PAWN, BLACK_BISHOP, BLACK_ROOK, BLACK_QUEEN, BLACK_KING
+	pawnAttacksIdx := 0
+	if !position.isWhiteTurn {
+		knight, pawn, bishop, rook, queen, king = WHITE_KNIGHT, WHITE_PAWN, WHITE_BISHOP, WHITE_ROOK, WHITE_QUEEN, WHITE_KING
+		pawnAttacksIdx = 1
+	}
+	pieces := &position.pieceBitboards
+	if tables.knight[idx]&pieces[knight]&attackers != 0 {
+		return true
+	}
+	if tables.pawn[pawnAttacksIdx][idx]&pieces[pawn]&attackers != 0 {
+		return true
+	}
+	if tables.bishopAttacks(idx, occupancy)&(pieces[bishop]|pieces[queen])&attackers != 0 {
+		return true
+	}
+	if tables.rookAttacks(idx, occupancy)&(pieces[rook]|pieces[queen])&attackers != 0 {
+		return true
+	}
+	return isKingIncluded && tables.king[idx]&pieces[king]&attackers != 0
+}
+
+// checkingSquaresAfter returns the squares the pieces of the mover check the enemy king from after the move, in the
+// order GetCheckingSquares lists them
+func (position *Position) checkingSquaresAfter(move *Move) []*Square {
+	checkingSquares := make([]*Square, 0)
+	enemyKingIdx := position.kingIdx(!position.isWhiteTurn)
+	if enemyKingIdx < 0 {
+		return checkingSquares
+	}
+	tables := getAttackTables()
+	fromIdx, toIdx := squareIndex(move.StartSquare), squareIndex(move.EndSquare)
+	landingPiece := move.Piece
+	if move.PawnUpgradedTo != EMPTY {
+		landingPiece = move.PawnUpgradedTo
+	}
+	occupancy := position.whitePieces | position.blackPieces
+	occupancy = occupancy&^(Bitboard(1)<<fromIdx) | Bitboard(1)<<toIdx
+	if move.Piece.IsPawn() && toIdx == position.enPassantIdx {
+		occupancy &^= Bitboard(1) << (fromIdx/8*8 + toIdx%8)
+	}
+	piecesAfter := func(piece Piece) Bitboard {
+		bitboard := position.pieceBitboards[piece]
+		if piece == move.Piece {
+			bitboard &^= Bitboard(1) << fromIdx
+		}
+		if piece == landingPiece {
+			bitboard |= Bitboard(1) << toIdx
+		}
+		return bitboard
+	}
+
+	knight, pawn, bishop, rook, queen := WHITE_KNIGHT, WHITE_PAWN, WHITE_BISHOP, WHITE_ROOK, WHITE_QUEEN
+	pawnAttacksIdx := 1
+	if !position.isWhiteTurn {
+		knight, pawn, bishop, rook, queen = BLACK_KNIGHT, BLACK_PAWN, BLACK_BISHOP, BLACK_ROOK, BLACK_QUEEN
+		pawnAttacksIdx = 0
+	}
+	for knights := tables.knight[enemyKingIdx] & piecesAfter(knight); knights != 0; {
+		checkIdx := knights.highestIndex()
+		knights &^= Bitboard(1) << checkIdx
+		checkingSquares = append(checkingSquares, squareFromIndex(checkIdx))
+	}
+	for pawns := tables.pawn[pawnAttacksIdx][enemyKingIdx] & piecesAfter(pawn); pawns != 0; pawns &= pawns - 1 {
+		checkingSquares = append(checkingSquares, squareFromIndex(pawns.lowestIndex()))
+	}
+	diagonalCheckers := piecesAfter(bishop) | piecesAfter(queen)
+	for _, dir := range diagonalDirections {
+		checkIdx := tables.nearestOnRay(enemyKingIdx, dir, occupancy)
+		if checkIdx >= 0 && diagonalCheckers&(Bitboard(1)<<checkIdx) != 0 {
+			checkingSquares = append(checkingSquares, squareFromIndex(checkIdx))
+		}
+	}
+	straightCheckers := piecesAfter(rook) | piecesAfter(queen)
+	for _, dir := range straightDirections {
+		checkIdx := tables.nearestOnRay(enemyKingIdx, dir, occupancy)
+		if checkIdx >= 0 && straightCheckers&(Bitboard(1)<<checkIdx) != 0 {
+			checkingSquares = append(checkingSquares, squareFromIndex(checkIdx))
+		}
+	}
+	return checkingSquares
+}
@@ -0,0 +1,177 @@
+package chess_test
+
+import (
+	"math/rand"
+	"testing"
+
+	. "github.com/CameronHonis/chess"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// POSITION_PERFT_MAX_DEPTH keeps the bitboard perft specs to the depths that run in seconds
+const POSITION_PERFT_MAX_DEPTH = 5
+
+// legalMovesByOrigin lists the moves the way GetLegalMoves did before it moved to bitboards
+func legalMovesByOrigin(board *Board) []*Move {
+	moves := make([]*Move, 0)
+	for rank := uint8(1); rank < 9; rank++ {
+		for file := uint8(1); file < 9; file++ {
+			squareMoves, _ := GetLegalMovesFromOrigin(board, &Square{rank, file})
+			moves = append(moves, squareMoves...)
+		}
+	}
+	return moves
+}
+
+var _ = Describe("Position", func() {
+	It("matches the perft data file", func() {
+		for _, line := range readPerftLines() {
+			fen, depthNodeCntPairs := parsePerftLine(line)
+			board, err := BoardFromFEN(fen)
+			Expect(err).ToNot(HaveOccurred())
+			position := NewPosition(board)
+			for _, depthNodeCntPair := range depthNodeCntPairs {
+				if depthNodeCntPair[0] > POSITION_PERFT_MAX_DEPTH {
+					continue
+				}
+				Expect(position.Perft(depthNodeCntPair[0])).To(Equal(uint64(depthNodeCntPair[1])), "%s at depth %d", fen, depthNodeCntPair[0])
+			}
+		}
+	})
+	It("matches the Chess960 perft counts", func() {
+		board, err := Chess960BoardFromFEN("bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w KQkq - 2 9")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(NewPosition(board).Perft(4)).To(Equal(uint64(326672)))
+	})
+	It("converts back to the same board", func() {
+		for _, line := range readPerftLines() {
+			fen, _ := parsePerftLine(line)
+			board, err := BoardFromFEN(fen)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(NewPosition(board).ToBoard().ToFEN()).To(Equal(board.ToFEN()))
+		}
+	})
+	Describe("LegalMoves", func() {
+		expectSameMoves := func(board *Board) {
+			moves, err := GetLegalMoves(board)
+			ExpectWithOffset(1, err).ToNot(HaveOccurred())
+			ExpectWithOffset(1, moves).To(Equal(legalMovesByOrigin(board)), board.ToFEN())
+			ExpectWithOffset(1, HasLegalMove(board)).To(Equal(len(moves) > 0))
+		}
+		It("lists the same moves in the same order as the generators by origin", func() {
+			for _, line := range readPerftLines() {
+				fen, _ := parsePerftLine(line)
+				board, err := BoardFromFEN(fen)
+				Expect(err).ToNot(HaveOccurred())
+				expectSameMoves(board)
+			}
+		})
+		It("lists the same moves as the generators by origin through random games", func() {
+			rng := rand.New(rand.NewSource(7))
+			for game := 0; game < 10; game++ {
+				board := GetInitBoard()
+				for board.Result == BOARD_RESULT_IN_PROGRESS {
+					expectSameMoves(board)
+					moves, _ := GetLegalMoves(board)
+					board = GetBoardFromMove(board, moves[rng.Intn(len(moves))])
+				}
+			}
+		})
+	})
+	Describe("AppendLegalMoves", func() {
+		It("appends the legal moves as values", func() {
+			board, err := BoardFromFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+			Expect(err).ToNot(HaveOccurred())
+			position := NewPosition(board)
+			moves := position.AppendLegalMoves(nil)
+			legalMoves := position.LegalMoves()
+			Expect(moves).To(HaveLen(len(legalMoves)))
+			for idx, move := range moves {
+				Expect(move.ToMove(position)).To(Equal(legalMoves[idx]))
+				Expect(NewPositionMove(legalMoves[idx])).To(Equal(move))
+			}
+		})
+	})
+	Describe("MakeMove", func() {
+		It("plays moves like GetBoardFromMove and takes them back with UnmakeMove", func() {
+			rng := rand.New(rand.NewSource(11))
+			for game := 0; game < 3; game++ {
+				board := GetInitBoard()
+				position := NewPosition(board)
+				for board.Result == BOARD_RESULT_IN_PROGRESS {
+					moves, _ := GetLegalMoves(board)
+					for _, move := range moves {
+						undo := position.MakeMove(NewPositionMove(move))
+						nextBoard := GetBoardFromMove(board, move)
+						Expect(position.ToBoard().ToFEN()).To(Equal(nextBoard.ToFEN()))
+						Expect(position.Key()).To(Equal(NewPosition(nextBoard).Key()), "%s after %s", board.ToFEN(), move.ToLongAlgebraic())
+						position.UnmakeMove(undo)
+						Expect(position.ToBoard().ToFEN()).To(Equal(board.ToFEN()))
+						Expect(position.Key()).To(Equal(NewPosition(board).Key()))
+					}
+					move := moves[rng.Intn(len(moves))]
+					position.MakeMove(NewPositionMove(move))
+					board = GetBoardFromMove(board, move)
+				}
+			}
+		})
+		It("castles and uncastles in Chess960 with the king landing on the rook's square", func() {
+			board, err := Chess960BoardFromFEN("bqnbrkrn/pppppp1p/6p1/8/8/6P1/PPPPPP1P/BQNBRKRN w GEge - 0 2")
+			Expect(err).ToNot(HaveOccurred())
+			position := NewPosition(board)
+			castle, err := MoveFromAlgebraic("O-O", board)
+			Expect(err).ToNot(HaveOccurred())
+			undo := position.MakeMove(NewPositionMove(castle))
+			Expect(position.ToBoard().ToFEN()).To(Equal(GetBoardFromMove(board, castle).ToFEN()))
+			position.UnmakeMove(undo)
+			Expect(position.ToBoard().ToFEN()).To(Equal(board.ToFEN()))
+		})
+		It("generates and plays moves without allocating", func() {
+			board, err := BoardFromFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+			Expect(err).ToNot(HaveOccurred())
+			position := NewPosition(board)
+			moves := make([]PositionMove, 0, 64)
+			allocs := testing.AllocsPerRun(100, func() {
+				moves = position.AppendLegalMoves(moves[:0])
+				for _, move := range moves {
+					undo := position.MakeMove(move)
+					position.UnmakeMove(undo)
+				}
+			})
+			Expect(allocs).To(BeZero())
+		})
+	})
+	Describe("Key", func() {
+		keyOf := func(fen string) uint64 {
+			board, err := BoardFromFEN(fen)
+			ExpectWithOffset(1, err).ToNot(HaveOccurred())
+			return NewPosition(board).Key()
+		}
+		It("is the same for transposed move orders", func() {
+			board := GetInitBoard()
+			for _, moves := range [][]string{{"Nf3", "Nf6", "Nc3"}, {"Nc3", "Nf6", "Nf3"}} {
+				position := NewPosition(board)
+				nextBoard := board
+				for _, san := range moves {
+					move, err := MoveFromAlgebraic(san, nextBoard)
+					Expect(err).ToNot(HaveOccurred())
+					position.MakeMove(NewPositionMove(move))
+					nextBoard = GetBoardFromMove(nextBoard, move)
+				}
+				Expect(position.Key()).To(Equal(keyOf("rnbqkb1r/pppppppp/5n2/8/8/2N2N2/PPPPPPPP/R1BQKB1R b KQkq - 3 2")))
+			}
+		})
+		It("tells apart the side to move and the castle rights", func() {
+			key := keyOf("r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1")
+			Expect(keyOf("r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1")).ToNot(Equal(key))
+			Expect(keyOf("r3k2r/8/8/8/8/8/8/R3K2R w Qkq - 0 1")).ToNot(Equal(key))
+		})
+		It("counts the en passant file only when a pawn can capture", func() {
+			Expect(keyOf("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1")).
+				To(Equal(keyOf("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1")))
+			Expect(keyOf("rnbqkbnr/ppp1pppp/8/8/3pP3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1")).
+				ToNot(Equal(keyOf("rnbqkbnr/ppp1pppp/8/8/3pP3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1")))
+		})
+	})
+})
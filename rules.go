@@ -0,0 +1,111 @@
+package chess
+
+type InsufficientMaterialPolicy string
+
+const (
+	// INSUFFICIENT_MATERIAL_POLICY_NONE never ends the game for lack of material
+	INSUFFICIENT_MATERIAL_POLICY_NONE InsufficientMaterialPolicy = ""
+	// INSUFFICIENT_MATERIAL_POLICY_SIMPLE draws when neither side has more than a lone minor piece or bishops of
+	// one color, see Board.IsForcedDrawByMaterial
+	INSUFFICIENT_MATERIAL_POLICY_SIMPLE InsufficientMaterialPolicy = "simple"
+	// INSUFFICIENT_MATERIAL_POLICY_DEAD_POSITION draws when neither side could checkmate by any sequence of legal
+	// moves, see Board.HasMatingMaterial. Unlike the simple policy, e.g. a knight each is not a draw.
+	INSUFFICIENT_MATERIAL_POLICY_DEAD_POSITION InsufficientMaterialPolicy = "dead_position"
+)
+
+// Rules decides when a repeated position or a long run of moves without a capture or pawn move draws the game.
+// Draws at the claim thresholds only happen when the player to move claims them, while draws at the automatic
+// thresholds end the game on their own. A threshold of 0 disables that rule.
+type Rules struct {
+	ClaimRepetitions uint8 `json:"claimRepetitions"`
+	AutoRepetitions  uint8 `json:"autoRepetitions"`
+	// ClaimHalfMoves and AutoHalfMoves count plies, so the fifty move rule is 100 half moves
+	ClaimHalfMoves             uint8                      `json:"claimHalfMoves"`
+	AutoHalfMoves              uint8                      `json:"autoHalfMoves"`
+	InsufficientMaterialPolicy InsufficientMaterialPolicy `json:"insufficientMaterialPolicy"`
+}
+
+// FIDERules are the FIDE Laws of Chess: threefold repetition and the fifty move rule may be claimed, while
+// fivefold repetition, the seventy-five move rule and dead positions draw automatically
+func FIDERules() *Rules {
+	return &Rules{
+		ClaimRepetitions:           3,
+		AutoRepetitions:            5,
+		ClaimHalfMoves:             100,
+		AutoHalfMoves:              150,
+		InsufficientMaterialPolicy: INSUFFICIENT_MATERIAL_POLICY_DEAD_POSITION,
+	}
+}
+
+// OnlineRules suit fast online play, where nobody has time to claim: threefold repetition and the fifty move
+// rule draw automatically
+func OnlineRules() *Rules {
+	return &Rules{
+		AutoRepetitions:            3,
+		AutoHalfMoves:              100,
+		InsufficientMaterialPolicy: INSUFFICIENT_MATERIAL_POLICY_DEAD_POSITION,
+	}
+}
+
+// PuzzleRules are the FIDE rules without any move count rule, since puzzles often start from positions with a
+// made up half move clock
+func PuzzleRules() *Rules {
+	rules := FIDERules()
+	rules.ClaimHalfMoves = 0
+	rules.AutoHalfMoves = 0
+	return rules
+}
+
+// LegacyRules match how boards were drawn before draws could be claimed: automatically on the third
+// repetition and after 50 half moves, along with the simple insufficient material policy. Note that 50 half
+// moves are only 25 moves, which is still reported as the fifty move rule for compatibility.
+func LegacyRules() *Rules {
+	return &Rules{
+		AutoRepetitions:            3,
+		AutoHalfMoves:              50,
+		InsufficientMaterialPolicy: INSUFFICIENT_MATERIAL_POLICY_SIMPLE,
+	}
+}
+
+func (rules *Rules) IsAutoDrawByRepetition(repetitions uint8) bool {
+	return rules.AutoRepetitions > 0 && repetitions >= rules.AutoRepetitions
+}
+
+func (rules *Rules) IsAutoDrawByHalfMoves(halfMoveClockCount uint8) bool {
+	return rules.AutoHalfMoves > 0 && halfMoveClockCount >= rules.AutoHalfMoves
+}
+
+func (rules *Rules) IsClaimableDrawByRepetition(repetitions uint8) bool {
+	return rules.ClaimRepetitions > 0 && repetitions >= rules.ClaimRepetitions
+}
+
+func (rules *Rules) IsClaimableDrawByHalfMoves(halfMoveClockCount uint8) bool {
+	return rules.ClaimHalfMoves > 0 && halfMoveClockCount >= rules.ClaimHalfMoves
+}
+
+func (rules *Rules) IsDrawByInsufficientMaterial(board *Board) bool {
+	switch rules.InsufficientMaterialPolicy {
+	case INSUFFICIENT_MATERIAL_POLICY_SIMPLE:
+		return board.IsForcedDrawByMaterial()
+	case INSUFFICIENT_MATERIAL_POLICY_DEAD_POSITION:
+		return !board.HasMatingMaterial(true) && !board.HasMatingMaterial(false)
+	default:
+		return false
+	}
+}
+
+// repetitionDrawResult names a repetition draw after the threshold of the rule that drew it
+func repetitionDrawResult(threshold uint8) BoardResult {
+	if threshold >= 5 {
+		return BOARD_RESULT_DRAW_BY_FIVEFOLD_REPETITION
+	}
+	return BOARD_RESULT_DRAW_BY_THREEFOLD_REPETITION
+}
+
+// halfMoveDrawResult names a half move draw after the threshold of the rule that drew it
+func halfMoveDrawResult(threshold uint8) BoardResult {
+	if threshold >= 150 {
+		return BOARD_RESULT_DRAW_BY_SEVENTY_FIVE_MOVE_RULE
+	}
+	return BOARD_RESULT_DRAW_BY_FIFTY_MOVE_RULE
+}
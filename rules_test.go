@@ -0,0 +1,85 @@
+package chess_test
+
+import (
+	"encoding/json"
+
+	. "github.com/CameronHonis/chess"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Rules", func() {
+	var boardWithRules = func(fen string, rules *Rules) *Board {
+		board, err := BoardFromFEN(fen)
+		Expect(err).ToNot(HaveOccurred())
+		// BoardFromFEN judges the position by the FIDE rules, so the result is reset for the given rules
+		return NewBoardBuilder().FromBoard(board).WithRules(rules).WithResult(BOARD_RESULT_IN_PROGRESS).Build()
+	}
+	knightMove := &Move{WHITE_KNIGHT, &Square{1, 2}, &Square{3, 3}, EMPTY, []*Square{}, EMPTY}
+
+	DescribeTable("automatic draws by move count", func(rules *Rules, halfMoveClockCount int, expResult BoardResult) {
+		board := GetInitBoard()
+		board.HalfMoveClockCount = uint8(halfMoveClockCount)
+		board = NewBoardBuilder().FromBoard(board).WithRules(rules).Build()
+		Expect(GetBoardFromMove(board, knightMove).Result).To(Equal(expResult))
+	},
+		Entry("FIDE at fifty moves", FIDERules(), 99, BOARD_RESULT_IN_PROGRESS),
+		Entry("FIDE at seventy five moves", FIDERules(), 149, BOARD_RESULT_DRAW_BY_SEVENTY_FIVE_MOVE_RULE),
+		Entry("online at fifty moves", OnlineRules(), 99, BOARD_RESULT_DRAW_BY_FIFTY_MOVE_RULE),
+		Entry("puzzle at seventy five moves", PuzzleRules(), 149, BOARD_RESULT_IN_PROGRESS),
+		Entry("legacy at fifty half moves", LegacyRules(), 49, BOARD_RESULT_DRAW_BY_FIFTY_MOVE_RULE),
+		Entry("online past seventy five moves", OnlineRules(), 199, BOARD_RESULT_DRAW_BY_FIFTY_MOVE_RULE),
+	)
+
+	It("stops the half move clock at its limit when no half move rule applies", func() {
+		board := GetInitBoard()
+		board.HalfMoveClockCount = 255
+		board = NewBoardBuilder().FromBoard(board).WithRules(PuzzleRules()).Build()
+		Expect(GetBoardFromMove(board, knightMove).HalfMoveClockCount).To(Equal(uint8(255)))
+	})
+
+	It("carries the rules through JSON", func() {
+		board := NewBoardBuilder().FromBoard(GetInitBoard()).WithRules(LegacyRules()).Build()
+		boardJson, err := json.Marshal(board)
+		Expect(err).ToNot(HaveOccurred())
+		decodedBoard := &Board{}
+		Expect(json.Unmarshal(boardJson, decodedBoard)).To(Succeed())
+		Expect(decodedBoard.Rules()).To(Equal(LegacyRules()))
+	})
+
+	DescribeTable("automatic draws by repetition", func(rules *Rules, repetitions int, expResult BoardResult) {
+		board := NewBoardBuilder().FromBoard(GetInitBoard()).WithRules(rules).Build()
+		nextKey := GetBoardFromMove(board, knightMove).ZobristKey()
+		board = NewBoardBuilder().FromBoard(board).WithKeyCount(nextKey, uint8(repetitions-1)).Build()
+		Expect(GetBoardFromMove(board, knightMove).Result).To(Equal(expResult))
+	},
+		Entry("FIDE on the third repetition", FIDERules(), 3, BOARD_RESULT_IN_PROGRESS),
+		Entry("FIDE on the fifth repetition", FIDERules(), 5, BOARD_RESULT_DRAW_BY_FIVEFOLD_REPETITION),
+		Entry("online on the third repetition", OnlineRules(), 3, BOARD_RESULT_DRAW_BY_THREEFOLD_REPETITION),
+		Entry("legacy on the third repetition", LegacyRules(), 3, BOARD_RESULT_DRAW_BY_THREEFOLD_REPETITION),
+	)
+
+	DescribeTable("insufficient material policies", func(fen string, policy InsufficientMaterialPolicy, expResult BoardResult) {
+		rules := FIDERules()
+		rules.InsufficientMaterialPolicy = policy
+		board := boardWithRules(fen, rules)
+		move := &Move{WHITE_KING, &Square{4, 2}, &Square{4, 3}, EMPTY, []*Square{}, EMPTY}
+		Expect(GetBoardFromMove(board, move).Result).To(Equal(expResult))
+	},
+		Entry("simple, a knight each", "8/8/8/5N2/1K6/8/8/6nk w - - 0 1", INSUFFICIENT_MATERIAL_POLICY_SIMPLE, BOARD_RESULT_DRAW_BY_INSUFFICIENT_MATERIAL),
+		Entry("dead position, a knight each", "8/8/8/5N2/1K6/8/8/6nk w - - 0 1", INSUFFICIENT_MATERIAL_POLICY_DEAD_POSITION, BOARD_RESULT_IN_PROGRESS),
+		Entry("dead position, a lone knight", "8/8/8/5N2/1K6/8/8/7k w - - 0 1", INSUFFICIENT_MATERIAL_POLICY_DEAD_POSITION, BOARD_RESULT_DRAW_BY_INSUFFICIENT_MATERIAL),
+		Entry("none, a lone knight", "8/8/8/5N2/1K6/8/8/7k w - - 0 1", INSUFFICIENT_MATERIAL_POLICY_NONE, BOARD_RESULT_IN_PROGRESS),
+	)
+
+	Describe("threading through a Game", func() {
+		It("applies the game's rules to every move", func() {
+			game := NewGameWithRules(OnlineRules())
+			Expect(game.Rules()).To(Equal(OnlineRules()))
+			for _, san := range []string{"Nf3", "Nf6", "Ng1", "Ng8", "Nf3", "Nf6", "Ng1", "Ng8"} {
+				Expect(game.MoveSAN(san)).To(Succeed())
+			}
+			Expect(game.Outcome()).To(Equal(NewGameOutcome(GAME_RESULT_DRAW, GAME_RESULT_REASON_THREEFOLD_REPETITION)))
+		})
+	})
+})
@@ -1,7 +1,6 @@
 package chess
 
 import (
-	"errors"
 	"fmt"
 	"strings"
 )
@@ -51,13 +50,13 @@ func (s *Square) String() string {
 func SquareFromAlgebraicCoords(algCoords string) (*Square, error) {
 	runeCoords := []rune(strings.ToLower(algCoords))
 	if len(runeCoords) != 2 {
-		return nil, errors.New(fmt.Sprintf("invalid algebraicCoords %s: expected char length (2), got (%d)", algCoords, len(runeCoords)))
+		return nil, fmt.Errorf("%w %s: expected char length (2), got (%d)", ErrInvalidSquare, algCoords, len(runeCoords))
 	}
 	file := uint8(runeCoords[0]) - 96
 	rank := uint8(runeCoords[1]) - 48
 	square := Square{Rank: rank, File: file}
 	if !square.IsValidBoardSquare() {
-		return nil, errors.New(fmt.Sprintf("invalid algebraicCoords %s: coords outside of board", algCoords))
+		return nil, fmt.Errorf("%w %s: coords outside of board", ErrInvalidSquare, algCoords)
 	}
 	return &square, nil
 }
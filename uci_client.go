@@ -0,0 +1,452 @@
+package chess
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UCISearchResult is an engine's answer to "go". Info is the last info line reported for the best line.
+type UCISearchResult struct {
+	BestMove   *Move
+	PonderMove *Move
+	Info       *SearchInfo
+}
+
+// UCI_STOP_TIMEOUT is how long a cancelled search waits for the engine's best move by default
+const UCI_STOP_TIMEOUT = 5 * time.Second
+
+// UCIClient drives an external UCI engine, e.g. to analyze games. Its methods must not be called concurrently.
+type UCIClient struct {
+	Name    string
+	Author  string
+	Options []*UCIOption
+	// StopTimeout bounds the wait for the best move after a cancelled search sends "stop". An engine that
+	// doesn't answer in time is given up on: a launched engine is killed and its input is closed.
+	StopTimeout time.Duration
+
+	cmd        *exec.Cmd
+	in         io.WriteCloser
+	lines      chan string
+	board      *Board
+	isChess960 bool
+	closeOnce  sync.Once
+	// done is closed once the client stops reading lines, when it is closed or gives up on the engine. The
+	// reader then drops the engine's lines rather than blocking on a full buffer, and closes readerDone at EOF.
+	done        chan struct{}
+	doneOnce    sync.Once
+	readerDone  chan struct{}
+	isAbandoned bool
+}
+
+// StartUCIClient launches the engine at path and performs the UCI handshake. Cancelling ctx kills the engine.
+func StartUCIClient(ctx context.Context, path string, args ...string) (*UCIClient, error) {
+	cmd := exec.CommandContext(ctx, path, args...)
+	in, inErr := cmd.StdinPipe()
+	if inErr != nil {
+		return nil, fmt.Errorf("cannot start engine %s: %w", path, inErr)
+	}
+	out, outErr := cmd.StdoutPipe()
+	if outErr != nil {
+		return nil, fmt.Errorf("cannot start engine %s: %w", path, outErr)
+	}
+	if startErr := cmd.Start(); startErr != nil {
+		return nil, fmt.Errorf("cannot start engine %s: %w", path, startErr)
+	}
+	client := newUCIClient(out, in)
+	client.cmd = cmd
+	if err := client.handshake(ctx); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// NewUCIClient performs the UCI handshake with an engine reading commands from in and writing replies to out,
+// e.g. an engine over in-memory pipes
+func NewUCIClient(ctx context.Context, out io.Reader, in io.WriteCloser) (*UCIClient, error) {
+	client := newUCIClient(out, in)
+	if err := client.handshake(ctx); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+func newUCIClient(out io.Reader, in io.WriteCloser) *UCIClient {
+	client := &UCIClient{
+		StopTimeout: UCI_STOP_TIMEOUT,
+		in:          in,
+		lines:       make(chan string, 64),
+		board:       GetInitBoard(),
+		done:        make(chan struct{}),
+		readerDone:  make(chan struct{}),
+	}
+	go func() {
+		defer close(client.readerDone)
+		scanner := bufio.NewScanner(out)
+		for scanner.Scan() {
+			select {
+			case client.lines <- scanner.Text():
+			case <-client.done:
+			}
+		}
+		close(client.lines)
+	}()
+	return client
+}
+
+func (client *UCIClient) handshake(ctx context.Context) error {
+	if err := client.send("uci"); err != nil {
+		return err
+	}
+	for {
+		line, err := client.readLine(ctx)
+		if err != nil {
+			return fmt.Errorf("cannot complete UCI handshake: %w", err)
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "id":
+			if len(fields) >= 2 && fields[1] == "name" {
+				client.Name = strings.Join(fields[2:], " ")
+			} else if len(fields) >= 2 && fields[1] == "author" {
+				client.Author = strings.Join(fields[2:], " ")
+			}
+		case "option":
+			client.Options = append(client.Options, parseUCIOption(fields[1:]))
+		case "uciok":
+			return nil
+		}
+	}
+}
+
+// IsReady waits for the engine to finish processing the commands sent so far
+func (client *UCIClient) IsReady(ctx context.Context) error {
+	if err := client.send("isready"); err != nil {
+		return err
+	}
+	for {
+		line, err := client.readLine(ctx)
+		if err != nil {
+			return fmt.Errorf("cannot wait for engine: %w", err)
+		}
+		if strings.TrimSpace(line) == "readyok" {
+			return nil
+		}
+	}
+}
+
+func (client *UCIClient) SetOption(ctx context.Context, name string, value string) error {
+	command := "setoption name " + name
+	if value != "" {
+		command += " value " + value
+	}
+	if err := client.send(command); err != nil {
+		return err
+	}
+	return client.IsReady(ctx)
+}
+
+func (client *UCIClient) NewGame(ctx context.Context) error {
+	if err := client.send("ucinewgame"); err != nil {
+		return err
+	}
+	return client.IsReady(ctx)
+}
+
+// SetPosition sends the position reached by playing the moves from the board. Sending the game's history
+// rather than only its last position lets the engine see repetitions.
+func (client *UCIClient) SetPosition(ctx context.Context, board *Board, moves []*Move) error {
+	if board.IsChess960 && !client.isChess960 {
+		if err := client.SetOption(ctx, UCI_CHESS960_OPTION, "true"); err != nil {
+			return err
+		}
+		client.isChess960 = true
+	}
+	var builder strings.Builder
+	if board.IsInitBoard() && !board.IsChess960 {
+		builder.WriteString("position startpos")
+	} else {
+		builder.WriteString("position fen " + board.ToFEN())
+	}
+	if len(moves) > 0 {
+		builder.WriteString(" moves")
+	}
+	lastBoard := board
+	for _, move := range moves {
+		builder.WriteString(" " + move.ToLongAlgebraic())
+		lastBoard = GetBoardFromMove(lastBoard, move)
+	}
+	if err := client.send(builder.String()); err != nil {
+		return err
+	}
+	client.board = lastBoard
+	return nil
+}
+
+// Go searches the position last sent with SetPosition, calling onInfo, which may be nil, for every info line
+// with a score. Cancelling ctx sends "stop" and returns the engine's best move so far. If the engine doesn't
+// answer within StopTimeout, it is given up on and ctx's error is returned.
+func (client *UCIClient) Go(ctx context.Context, limits *SearchLimits, onInfo func(*SearchInfo)) (*UCISearchResult, error) {
+	if err := client.send("go" + formatSearchLimits(limits)); err != nil {
+		return nil, err
+	}
+	result := &UCISearchResult{}
+	readCtx := ctx
+	isStopping := false
+	for {
+		line, err := client.readLine(readCtx)
+		if err == readCtx.Err() && err != nil {
+			if isStopping {
+				client.abandon()
+				return nil, ctx.Err()
+			}
+			if stopErr := client.send("stop"); stopErr != nil {
+				return nil, stopErr
+			}
+			isStopping = true
+			var cancel context.CancelFunc
+			readCtx, cancel = context.WithTimeout(context.Background(), client.StopTimeout)
+			defer cancel()
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read search result: %w", err)
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "info":
+			info, ok := parseSearchInfo(fields[1:], client.board)
+			if !ok {
+				continue
+			}
+			if info.MultiPV <= 1 {
+				result.Info = info
+			}
+			if onInfo != nil {
+				onInfo(info)
+			}
+		case "bestmove":
+			return client.readBestMove(fields[1:], result)
+		}
+	}
+}
+
+func (client *UCIClient) readBestMove(args []string, result *UCISearchResult) (*UCISearchResult, error) {
+	if len(args) == 0 || args[0] == "0000" || args[0] == "(none)" {
+		return result, nil
+	}
+	bestMove, err := MoveFromLongAlgebraic(args[0], client.board)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read best move: %w", err)
+	}
+	result.BestMove = bestMove
+	if len(args) >= 3 && args[1] == "ponder" {
+		if ponderMove, ponderErr := MoveFromLongAlgebraic(args[2], GetBoardFromMove(client.board, bestMove)); ponderErr == nil {
+			result.PonderMove = ponderMove
+		}
+	}
+	return result, nil
+}
+
+// Close sends "quit" and waits for a launched engine to exit, killing it if it hasn't exited after StopTimeout
+func (client *UCIClient) Close() error {
+	var err error
+	client.closeOnce.Do(func() {
+		// lines left unread would block the reader, and the engine writing them would never read "quit"
+		client.stopReading()
+		_ = client.send("quit")
+		err = client.in.Close()
+		if client.cmd == nil {
+			return
+		}
+		// the engine's output is read to the end before Wait closes it
+		select {
+		case <-client.readerDone:
+		case <-time.After(client.StopTimeout):
+			_ = client.cmd.Process.Kill()
+			<-client.readerDone
+		}
+		// a killed engine exits with an error, which was already reported when it was given up on
+		if waitErr := client.cmd.Wait(); waitErr != nil && err == nil && !client.isAbandoned {
+			err = waitErr
+		}
+	})
+	return err
+}
+
+// abandon gives up on an unresponsive engine, killing a launched engine and closing its input
+func (client *UCIClient) abandon() {
+	if client.isAbandoned {
+		return
+	}
+	client.isAbandoned = true
+	client.stopReading()
+	_ = client.in.Close()
+	if client.cmd != nil {
+		_ = client.cmd.Process.Kill()
+	}
+}
+
+func (client *UCIClient) stopReading() {
+	client.doneOnce.Do(func() {
+		close(client.done)
+	})
+}
+
+func (client *UCIClient) send(command string) error {
+	if _, err := io.WriteString(client.in, command+"\n"); err != nil {
+		return fmt.Errorf("cannot send %s to engine: %w", strings.Fields(command)[0], err)
+	}
+	return nil
+}
+
+func (client *UCIClient) readLine(ctx context.Context) (string, error) {
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case line, ok := <-client.lines:
+		if !ok {
+			return "", io.ErrUnexpectedEOF
+		}
+		return line, nil
+	}
+}
+
+func formatSearchLimits(limits *SearchLimits) string {
+	if limits == nil {
+		return ""
+	}
+	var builder strings.Builder
+	writeMillis := func(name string, duration time.Duration) {
+		if duration > 0 {
+			builder.WriteString(fmt.Sprintf(" %s %d", name, duration.Milliseconds()))
+		}
+	}
+	if limits.Depth > 0 {
+		builder.WriteString(fmt.Sprintf(" depth %d", limits.Depth))
+	}
+	if limits.Nodes > 0 {
+		builder.WriteString(fmt.Sprintf(" nodes %d", limits.Nodes))
+	}
+	writeMillis("movetime", limits.MoveTime)
+	writeMillis("wtime", limits.WhiteTime)
+	writeMillis("btime", limits.BlackTime)
+	writeMillis("winc", limits.WhiteIncrement)
+	writeMillis("binc", limits.BlackIncrement)
+	if limits.MovesToGo > 0 {
+		builder.WriteString(fmt.Sprintf(" movestogo %d", limits.MovesToGo))
+	}
+	if limits.Mate > 0 {
+		builder.WriteString(fmt.Sprintf(" mate %d", limits.Mate))
+	}
+	if limits.IsInfinite {
+		builder.WriteString(" infinite")
+	}
+	if len(limits.SearchMoves) > 0 {
+		builder.WriteString(" searchmoves")
+		for _, move := range limits.SearchMoves {
+			builder.WriteString(" " + move.ToLongAlgebraic())
+		}
+	}
+	return builder.String()
+}
+
+// parseSearchInfo reads the fields of an info line, converting the PV into moves from the board. It skips
+// lines without a score, such as "info string" or "info currmove". A PV that stops being legal is cut short.
+func parseSearchInfo(args []string, board *Board) (*SearchInfo, bool) {
+	info := &SearchInfo{}
+	for idx := 0; idx < len(args); idx++ {
+		arg := args[idx]
+		if arg == "string" {
+			return nil, false
+		}
+		if arg == "pv" {
+			pvBoard := board
+			for _, longAlgMove := range args[idx+1:] {
+				move, err := MoveFromLongAlgebraic(longAlgMove, pvBoard)
+				if err != nil {
+					break
+				}
+				info.PV = append(info.PV, move)
+				pvBoard = GetBoardFromMove(pvBoard, move)
+			}
+			break
+		}
+		if idx+1 >= len(args) {
+			break
+		}
+		if arg == "score" && idx+2 < len(args) {
+			score, err := strconv.Atoi(args[idx+2])
+			if err == nil {
+				info.Score = score
+				info.IsMate = args[idx+1] == "mate"
+				info.HasScore = true
+			}
+			idx += 2
+			continue
+		}
+		value, err := strconv.ParseInt(args[idx+1], 10, 64)
+		if err != nil {
+			continue
+		}
+		idx++
+		switch arg {
+		case "depth":
+			info.Depth = int(value)
+		case "seldepth":
+			info.SelDepth = int(value)
+		case "multipv":
+			info.MultiPV = int(value)
+		case "nodes":
+			info.Nodes = uint64(value)
+		case "nps":
+			info.NPS = uint64(value)
+		case "time":
+			info.Time = time.Duration(value) * time.Millisecond
+		}
+	}
+	return info, info.HasScore
+}
+
+// parseUCIOption reads the fields of an option line after "option"
+func parseUCIOption(args []string) *UCIOption {
+	option := &UCIOption{}
+	keywords := map[string]bool{"name": true, "type": true, "default": true, "min": true, "max": true, "var": true}
+	for idx := 0; idx < len(args); {
+		keyword := args[idx]
+		idx++
+		valueStart := idx
+		for idx < len(args) && !keywords[args[idx]] {
+			idx++
+		}
+		value := strings.Join(args[valueStart:idx], " ")
+		switch keyword {
+		case "name":
+			option.Name = value
+		case "type":
+			option.Type = UCIOptionType(value)
+		case "default":
+			option.Default = value
+		case "min":
+			option.Min, _ = strconv.Atoi(value)
+		case "max":
+			option.Max, _ = strconv.Atoi(value)
+		case "var":
+			option.Vars = append(option.Vars, value)
+		}
+	}
+	return option
+}
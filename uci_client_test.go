@@ -0,0 +1,235 @@
+package chess_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	. "github.com/CameronHonis/chess"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UCIClient", func() {
+	var ctx context.Context
+	BeforeEach(func() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+		DeferCleanup(cancel)
+	})
+	Describe("against an engine over pipes", func() {
+		var searcher *firstMoveSearcher
+		var client *UCIClient
+		BeforeEach(func() {
+			searcher = &firstMoveSearcher{options: make(map[string]string)}
+			engine := NewUCIEngine("TestEngine", "Tester", searcher)
+			engineIn, clientOut := io.Pipe()
+			clientIn, engineOut := io.Pipe()
+			runErr := make(chan error, 1)
+			go func() {
+				runErr <- engine.Run(engineIn, engineOut)
+				engineOut.Close()
+			}()
+			var err error
+			client, err = NewUCIClient(ctx, clientIn, clientOut)
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(func() {
+				Expect(client.Close()).To(Succeed())
+				Eventually(runErr).Should(Receive(BeNil()))
+			})
+		})
+		It("reads the engine's id and options in the handshake", func() {
+			Expect(client.Name).To(Equal("TestEngine"))
+			Expect(client.Author).To(Equal("Tester"))
+			Expect(client.Options).To(ContainElement(&UCIOption{
+				Name: "Hash", Type: UCI_OPTION_TYPE_SPIN, Default: "16", Min: 1, Max: 1024,
+			}))
+		})
+		It("sets options", func() {
+			Expect(client.SetOption(ctx, "Hash", "64")).To(Succeed())
+			Expect(searcher.options).To(HaveKeyWithValue("Hash", "64"))
+		})
+		It("searches the position reached by the moves", func() {
+			board := GetInitBoard()
+			e4, _ := MoveFromAlgebraic("e4", board)
+			Expect(client.NewGame(ctx)).To(Succeed())
+			Expect(client.SetPosition(ctx, board, []*Move{e4})).To(Succeed())
+			infos := make([]*SearchInfo, 0)
+			result, err := client.Go(ctx, &SearchLimits{Depth: 3}, func(info *SearchInfo) {
+				infos = append(infos, info)
+			})
+			Expect(err).ToNot(HaveOccurred())
+			legalReplies, _ := GetLegalMoves(GetBoardFromMove(board, e4))
+			Expect(result.BestMove.ToLongAlgebraic()).To(Equal(legalReplies[0].ToLongAlgebraic()))
+			Expect(infos).To(HaveLen(1))
+			Expect(result.Info).To(Equal(infos[0]))
+			Expect(result.Info.Score).To(Equal(12))
+			Expect(result.Info.PV).To(HaveLen(1))
+			Expect(result.Info.PV[0].ToLongAlgebraic()).To(Equal(legalReplies[0].ToLongAlgebraic()))
+			Expect(searcher.boards[0].ToFEN()).To(Equal(GetBoardFromMove(board, e4).ToFEN()))
+			Expect(searcher.limits[0].Depth).To(Equal(3))
+		})
+		It("sends mate and search moves limits", func() {
+			board := GetInitBoard()
+			e4, _ := MoveFromAlgebraic("e4", board)
+			Expect(client.SetPosition(ctx, board, nil)).To(Succeed())
+			_, err := client.Go(ctx, &SearchLimits{Mate: 2, SearchMoves: []*Move{e4}}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(searcher.limits[0].Mate).To(Equal(2))
+			Expect(searcher.limits[0].SearchMoves).To(HaveLen(1))
+			Expect(searcher.limits[0].SearchMoves[0].ToLongAlgebraic()).To(Equal("e2e4"))
+		})
+		It("sends a Chess960 position as a FEN", func() {
+			board, _ := Chess960BoardFromFEN("bqnbrkrn/pppppppp/8/8/8/8/PPPPPPPP/BQNBRKRN w GEge - 0 1")
+			Expect(client.SetPosition(ctx, board, nil)).To(Succeed())
+			_, err := client.Go(ctx, &SearchLimits{Depth: 1}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(searcher.boards[0].IsChess960).To(BeTrue())
+			Expect(searcher.boards[0].ToFEN()).To(Equal(board.ToFEN()))
+		})
+		It("stops the search when the context is cancelled", func() {
+			Expect(client.SetPosition(ctx, GetInitBoard(), nil)).To(Succeed())
+			goCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+			defer cancel()
+			result, err := client.Go(goCtx, &SearchLimits{IsInfinite: true}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.BestMove.ToLongAlgebraic()).To(Equal("b1a3"))
+		})
+	})
+	Describe("against a scripted engine", func() {
+		var client *UCIClient
+		var replies map[string][]string
+		var engineDone chan struct{}
+		BeforeEach(func() {
+			replies = map[string][]string{
+				"uci": {"id name Scripted", "option name Skill Level type combo default Easy var Easy var Hard", "uciok"},
+			}
+			engineIn, clientOut := io.Pipe()
+			clientIn, engineOut := io.Pipe()
+			engineDone = make(chan struct{})
+			go func() {
+				defer close(engineDone)
+				defer engineOut.Close()
+				scanner := bufio.NewScanner(engineIn)
+				for scanner.Scan() {
+					command := strings.Fields(scanner.Text())[0]
+					if command == "quit" {
+						return
+					}
+					for _, reply := range replies[command] {
+						if reply == "exit" {
+							engineIn.Close()
+							return
+						}
+						fmt.Fprintln(engineOut, reply)
+					}
+				}
+			}()
+			var err error
+			client, err = NewUCIClient(ctx, clientIn, clientOut)
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(client.Close)
+		})
+		It("reads options with names and values containing spaces", func() {
+			Expect(client.Name).To(Equal("Scripted"))
+			Expect(client.Options).To(Equal([]*UCIOption{{
+				Name: "Skill Level", Type: UCI_OPTION_TYPE_COMBO, Default: "Easy", Vars: []string{"Easy", "Hard"},
+			}}))
+		})
+		It("parses info lines into typed search info", func() {
+			replies["go"] = []string{
+				"info string starting search",
+				"info depth 1 currmove e2e4 currmovenumber 1",
+				"info depth 5 seldepth 8 multipv 1 score cp 31 nodes 12000 nps 240000 time 50 pv e2e4 e7e5 g1f3",
+				"info depth 5 seldepth 7 multipv 2 score mate -3 nodes 12000 nps 240000 time 50 pv d2d4 d7d5",
+				"bestmove e2e4 ponder e7e5",
+			}
+			Expect(client.SetPosition(ctx, GetInitBoard(), nil)).To(Succeed())
+			infos := make([]*SearchInfo, 0)
+			result, err := client.Go(ctx, &SearchLimits{Depth: 5}, func(info *SearchInfo) {
+				infos = append(infos, info)
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(infos).To(HaveLen(2))
+			Expect(result.BestMove.ToLongAlgebraic()).To(Equal("e2e4"))
+			Expect(result.PonderMove.ToLongAlgebraic()).To(Equal("e7e5"))
+			Expect(result.Info).To(Equal(infos[0]))
+			Expect(infos[0].Depth).To(Equal(5))
+			Expect(infos[0].SelDepth).To(Equal(8))
+			Expect(infos[0].MultiPV).To(Equal(1))
+			Expect(infos[0].Score).To(Equal(31))
+			Expect(infos[0].HasScore).To(BeTrue())
+			Expect(infos[0].IsMate).To(BeFalse())
+			Expect(infos[0].Nodes).To(Equal(uint64(12000)))
+			Expect(infos[0].NPS).To(Equal(uint64(240000)))
+			Expect(infos[0].Time).To(Equal(50 * time.Millisecond))
+			Expect(infos[0].PV).To(HaveLen(3))
+			Expect(infos[0].PV[2].ToLongAlgebraic()).To(Equal("g1f3"))
+			Expect(infos[1].MultiPV).To(Equal(2))
+			Expect(infos[1].Score).To(Equal(-3))
+			Expect(infos[1].IsMate).To(BeTrue())
+		})
+		It("cuts a PV short at the first illegal move", func() {
+			replies["go"] = []string{"info depth 2 score cp 10 pv e2e4 e2e4", "bestmove e2e4"}
+			Expect(client.SetPosition(ctx, GetInitBoard(), nil)).To(Succeed())
+			result, err := client.Go(ctx, nil, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Info.PV).To(HaveLen(1))
+		})
+		It("returns no best move when the engine has none", func() {
+			replies["go"] = []string{"bestmove (none)"}
+			Expect(client.SetPosition(ctx, GetInitBoard(), nil)).To(Succeed())
+			result, err := client.Go(ctx, nil, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.BestMove).To(BeNil())
+		})
+		It("gives up on an engine that doesn't answer stop", func() {
+			replies["go"] = []string{"info depth 1 score cp 10 pv e2e4"}
+			Expect(client.SetPosition(ctx, GetInitBoard(), nil)).To(Succeed())
+			client.StopTimeout = 20 * time.Millisecond
+			goCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+			defer cancel()
+			_, err := client.Go(goCtx, &SearchLimits{IsInfinite: true}, nil)
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+			Expect(client.IsReady(ctx)).ToNot(Succeed())
+		})
+		It("closes with the engine's unread lines filling the buffer", func() {
+			for idx := 0; idx < 100; idx++ {
+				replies["position"] = append(replies["position"], fmt.Sprintf("info depth %d score cp 10", idx+1))
+			}
+			Expect(client.SetPosition(ctx, GetInitBoard(), nil)).To(Succeed())
+			closeErr := make(chan error, 1)
+			go func() {
+				closeErr <- client.Close()
+			}()
+			Eventually(closeErr, time.Second).Should(Receive(BeNil()))
+			Eventually(engineDone, time.Second).Should(BeClosed())
+		})
+		It("errors when the engine exits mid-search", func() {
+			replies["go"] = []string{"info depth 1 score cp 10 pv e2e4", "exit"}
+			Expect(client.SetPosition(ctx, GetInitBoard(), nil)).To(Succeed())
+			_, err := client.Go(ctx, &SearchLimits{Depth: 1}, nil)
+			Expect(err).To(MatchError(io.ErrUnexpectedEOF))
+		})
+	})
+	Describe("against an engine process", func() {
+		It("launches the engine and searches", func() {
+			testBinary, err := os.Executable()
+			Expect(err).ToNot(HaveOccurred())
+			os.Setenv(UCI_ENGINE_ENV, "1")
+			client, startErr := StartUCIClient(ctx, testBinary)
+			os.Unsetenv(UCI_ENGINE_ENV)
+			Expect(startErr).ToNot(HaveOccurred())
+			Expect(client.Name).To(Equal("TestEngine"))
+			Expect(client.SetPosition(ctx, GetInitBoard(), nil)).To(Succeed())
+			result, goErr := client.Go(ctx, &SearchLimits{Depth: 1}, nil)
+			Expect(goErr).ToNot(HaveOccurred())
+			Expect(result.BestMove.ToLongAlgebraic()).To(Equal("b1a3"))
+			Expect(client.Close()).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,414 @@
+package chess
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SearchLimits are the limits of a search, from a UCI "go" command or the CECP time controls. Zero values are
+// unset.
+type SearchLimits struct {
+	Depth          int
+	Nodes          uint64
+	MoveTime       time.Duration
+	WhiteTime      time.Duration
+	BlackTime      time.Duration
+	WhiteIncrement time.Duration
+	BlackIncrement time.Duration
+	MovesToGo      int
+	IsInfinite     bool
+	// Mate asks for a mate in at most this many moves
+	Mate int
+	// SearchMoves restricts the search to these moves of the side to move
+	SearchMoves []*Move
+}
+
+// SearchInfo is the progress of a search, reported to the GUI as a UCI "info" line
+type SearchInfo struct {
+	Depth    int
+	SelDepth int
+	// MultiPV numbers the line from 1 when the engine reports several best lines
+	MultiPV int
+	// Score is in centipawns from the side to move's point of view, or in moves to mate when IsMate is set. It is
+	// only reported when HasScore is set, so that progress without an evaluation doesn't read as a score of 0.
+	Score    int
+	IsMate   bool
+	HasScore bool
+	Nodes    uint64
+	NPS      uint64
+	Time     time.Duration
+	PV       []*Move
+}
+
+// Searcher is the engine backend that answers "go". Search must return its best move promptly once ctx is
+// done, which happens on "stop", on "quit", or when the move time runs out. A nil move means there is no legal
+// move.
+type Searcher interface {
+	Search(ctx context.Context, board *Board, limits *SearchLimits, onInfo func(*SearchInfo)) (*Move, error)
+}
+
+// UCIOptionSearcher is a Searcher with options the GUI can set
+type UCIOptionSearcher interface {
+	Searcher
+	Options() []*UCIOption
+	SetOption(name string, value string) error
+}
+
+// NewGameSearcher is a Searcher that clears its state, e.g. hash tables, on "ucinewgame"
+type NewGameSearcher interface {
+	Searcher
+	NewGame()
+}
+
+type UCIOptionType string
+
+const (
+	UCI_OPTION_TYPE_CHECK  UCIOptionType = "check"
+	UCI_OPTION_TYPE_SPIN   UCIOptionType = "spin"
+	UCI_OPTION_TYPE_COMBO  UCIOptionType = "combo"
+	UCI_OPTION_TYPE_BUTTON UCIOptionType = "button"
+	UCI_OPTION_TYPE_STRING UCIOptionType = "string"
+)
+
+// UCIOption is an option announced in reply to "uci". Min and Max only apply to spin options and Vars only to
+// combo options.
+type UCIOption struct {
+	Name    string
+	Type    UCIOptionType
+	Default string
+	Min     int
+	Max     int
+	Vars    []string
+}
+
+func (option *UCIOption) String() string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("option name %s type %s", option.Name, option.Type))
+	if option.Type != UCI_OPTION_TYPE_BUTTON {
+		builder.WriteString(" default " + option.Default)
+	}
+	if option.Type == UCI_OPTION_TYPE_SPIN {
+		builder.WriteString(fmt.Sprintf(" min %d max %d", option.Min, option.Max))
+	}
+	for _, optionVar := range option.Vars {
+		builder.WriteString(" var " + optionVar)
+	}
+	return builder.String()
+}
+
+const UCI_CHESS960_OPTION = "UCI_Chess960"
+
+// UCIEngine speaks the UCI protocol to a GUI, keeping track of the position and handing searches to its
+// Searcher
+type UCIEngine struct {
+	Name   string
+	Author string
+
+	searcher   Searcher
+	board      *Board
+	isChess960 bool
+
+	outMu        sync.Mutex
+	out          io.Writer
+	cancelSearch context.CancelFunc
+	searchDone   chan struct{}
+}
+
+func NewUCIEngine(name string, author string, searcher Searcher) *UCIEngine {
+	return &UCIEngine{
+		Name:     name,
+		Author:   author,
+		searcher: searcher,
+		board:    GetInitBoard(),
+	}
+}
+
+// Run reads commands from in and writes replies to out until "quit" or the end of the input
+func (engine *UCIEngine) Run(in io.Reader, out io.Writer) error {
+	engine.out = out
+	defer engine.stopSearch()
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "quit" {
+			return nil
+		}
+		if err := engine.handleCommand(fields[0], fields[1:]); err != nil {
+			engine.writeLine("info string " + err.Error())
+		}
+	}
+	return scanner.Err()
+}
+
+func (engine *UCIEngine) handleCommand(command string, args []string) error {
+	switch command {
+	case "uci":
+		engine.writeLine("id name " + engine.Name)
+		engine.writeLine("id author " + engine.Author)
+		engine.writeLine((&UCIOption{Name: UCI_CHESS960_OPTION, Type: UCI_OPTION_TYPE_CHECK, Default: "false"}).String())
+		if optionSearcher, ok := engine.searcher.(UCIOptionSearcher); ok {
+			for _, option := range optionSearcher.Options() {
+				engine.writeLine(option.String())
+			}
+		}
+		engine.writeLine("uciok")
+	case "isready":
+		engine.writeLine("readyok")
+	case "ucinewgame":
+		engine.stopSearch()
+		engine.board = GetInitBoard()
+		if newGameSearcher, ok := engine.searcher.(NewGameSearcher); ok {
+			newGameSearcher.NewGame()
+		}
+	case "setoption":
+		return engine.setOption(args)
+	case "position":
+		engine.stopSearch()
+		return engine.setPosition(args)
+	case "go":
+		engine.stopSearch()
+		limits, err := parseSearchLimits(args, engine.board)
+		if err != nil {
+			return err
+		}
+		engine.startSearch(limits)
+	case "stop":
+		engine.stopSearch()
+	case "debug", "ponderhit", "register":
+	default:
+		return fmt.Errorf("unknown command %s", command)
+	}
+	return nil
+}
+
+func (engine *UCIEngine) setOption(args []string) error {
+	name, value, err := parseSetOption(args)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(name, UCI_CHESS960_OPTION) {
+		engine.isChess960 = value == "true"
+		return nil
+	}
+	optionSearcher, ok := engine.searcher.(UCIOptionSearcher)
+	if !ok {
+		return fmt.Errorf("cannot set option %s, engine has no options", name)
+	}
+	return optionSearcher.SetOption(name, value)
+}
+
+// parseSetOption reads "name <name> [value <value>]", where both the name and value may contain spaces
+func parseSetOption(args []string) (string, string, error) {
+	if len(args) < 2 || args[0] != "name" {
+		return "", "", fmt.Errorf("cannot set option, expected setoption name <name> [value <value>]")
+	}
+	nameArgs := args[1:]
+	var valueArgs []string
+	for idx, arg := range nameArgs {
+		if arg == "value" {
+			nameArgs, valueArgs = args[1:idx+1], args[idx+2:]
+			break
+		}
+	}
+	return strings.Join(nameArgs, " "), strings.Join(valueArgs, " "), nil
+}
+
+// setPosition reads "startpos|fen <fen> [moves <move>...]"
+func (engine *UCIEngine) setPosition(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("cannot set position, expected startpos or fen")
+	}
+	var board *Board
+	var moveArgs []string
+	switch args[0] {
+	case "startpos":
+		board = GetInitBoard()
+		moveArgs = args[1:]
+	case "fen":
+		fenArgs := args[1:]
+		for idx, arg := range fenArgs {
+			if arg == "moves" {
+				fenArgs, moveArgs = args[1:idx+1], args[idx+1:]
+				break
+			}
+		}
+		var fenErr error
+		board, fenErr = BoardFromFEN(strings.Join(fenArgs, " "))
+		if fenErr != nil {
+			return fmt.Errorf("cannot set position: %w", fenErr)
+		}
+	default:
+		return fmt.Errorf("cannot set position, expected startpos or fen, got %s", args[0])
+	}
+	if engine.isChess960 && !board.IsChess960 {
+		board = NewBoardBuilder().FromBoard(board).WithIsChess960(true).Build()
+	}
+
+	if len(moveArgs) > 0 {
+		if moveArgs[0] != "moves" {
+			return fmt.Errorf("cannot set position, expected moves, got %s", moveArgs[0])
+		}
+		for _, longAlgMove := range moveArgs[1:] {
+			move, moveErr := MoveFromLongAlgebraic(longAlgMove, board)
+			if moveErr != nil {
+				return fmt.Errorf("cannot set position: %w", moveErr)
+			}
+			board = GetBoardFromMove(board, move)
+		}
+	}
+	engine.board = board
+	return nil
+}
+
+// parseSearchLimits reads the arguments of "go". Pondering is rejected, since the engine doesn't offer the
+// Ponder option.
+func parseSearchLimits(args []string, board *Board) (*SearchLimits, error) {
+	limits := &SearchLimits{}
+	durationFields := map[string]*time.Duration{
+		"movetime": &limits.MoveTime,
+		"wtime":    &limits.WhiteTime,
+		"btime":    &limits.BlackTime,
+		"winc":     &limits.WhiteIncrement,
+		"binc":     &limits.BlackIncrement,
+	}
+	for idx := 0; idx < len(args); idx++ {
+		arg := args[idx]
+		switch arg {
+		case "infinite":
+			limits.IsInfinite = true
+			continue
+		case "ponder":
+			return nil, fmt.Errorf("cannot go, pondering is not supported")
+		case "searchmoves":
+			for idx+1 < len(args) && isSearchMove(args[idx+1]) {
+				idx++
+				move, moveErr := MoveFromLongAlgebraic(args[idx], board)
+				if moveErr != nil {
+					return nil, fmt.Errorf("cannot go, invalid search move: %w", moveErr)
+				}
+				limits.SearchMoves = append(limits.SearchMoves, move)
+			}
+			continue
+		case "depth", "nodes", "movestogo", "mate", "movetime", "wtime", "btime", "winc", "binc":
+		default:
+			return nil, fmt.Errorf("cannot go, unknown limit %s", arg)
+		}
+		if idx+1 >= len(args) {
+			return nil, fmt.Errorf("cannot go, missing value for %s", arg)
+		}
+		idx++
+		value, err := strconv.ParseInt(args[idx], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot go, invalid value %s for %s", args[idx], arg)
+		}
+		if durationField, ok := durationFields[arg]; ok {
+			*durationField = time.Duration(value) * time.Millisecond
+		} else if arg == "depth" {
+			limits.Depth = int(value)
+		} else if arg == "nodes" {
+			limits.Nodes = uint64(value)
+		} else if arg == "movestogo" {
+			limits.MovesToGo = int(value)
+		} else if arg == "mate" {
+			limits.Mate = int(value)
+		}
+	}
+	return limits, nil
+}
+
+func isSearchMove(arg string) bool {
+	return len(arg) >= 4 && isFileChar(arg[0]) && isRankChar(arg[1]) && isFileChar(arg[2]) && isRankChar(arg[3])
+}
+
+func (engine *UCIEngine) startSearch(limits *SearchLimits) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if limits.MoveTime > 0 && !limits.IsInfinite {
+		ctx, cancel = context.WithTimeout(context.Background(), limits.MoveTime)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	done := make(chan struct{})
+	engine.cancelSearch = cancel
+	engine.searchDone = done
+	// boards memoize derived state on first read, so the searcher gets its own copy
+	board := NewBoardBuilder().FromBoard(engine.board).Build()
+
+	go func() {
+		defer close(done)
+		move, err := engine.searcher.Search(ctx, board, limits, engine.writeInfo)
+		if err != nil {
+			engine.writeLine("info string search failed: " + err.Error())
+		}
+		// the GUI expects no bestmove for an infinite search until it sends stop
+		if limits.IsInfinite {
+			<-ctx.Done()
+		}
+		if move == nil {
+			engine.writeLine("bestmove 0000")
+		} else {
+			engine.writeLine("bestmove " + move.ToLongAlgebraic())
+		}
+	}()
+}
+
+// stopSearch cancels the running search and waits for its bestmove to be written
+func (engine *UCIEngine) stopSearch() {
+	if engine.cancelSearch == nil {
+		return
+	}
+	engine.cancelSearch()
+	<-engine.searchDone
+	engine.cancelSearch = nil
+	engine.searchDone = nil
+}
+
+func (engine *UCIEngine) writeInfo(info *SearchInfo) {
+	var builder strings.Builder
+	builder.WriteString("info")
+	if info.Depth > 0 {
+		builder.WriteString(fmt.Sprintf(" depth %d", info.Depth))
+	}
+	if info.SelDepth > 0 {
+		builder.WriteString(fmt.Sprintf(" seldepth %d", info.SelDepth))
+	}
+	if info.MultiPV > 0 {
+		builder.WriteString(fmt.Sprintf(" multipv %d", info.MultiPV))
+	}
+	if info.HasScore && info.IsMate {
+		builder.WriteString(fmt.Sprintf(" score mate %d", info.Score))
+	} else if info.HasScore {
+		builder.WriteString(fmt.Sprintf(" score cp %d", info.Score))
+	}
+	if info.Nodes > 0 {
+		builder.WriteString(fmt.Sprintf(" nodes %d", info.Nodes))
+	}
+	if info.NPS > 0 {
+		builder.WriteString(fmt.Sprintf(" nps %d", info.NPS))
+	}
+	if info.Time > 0 {
+		builder.WriteString(fmt.Sprintf(" time %d", info.Time.Milliseconds()))
+	}
+	if len(info.PV) > 0 {
+		builder.WriteString(" pv")
+		for _, move := range info.PV {
+			builder.WriteString(" " + move.ToLongAlgebraic())
+		}
+	}
+	engine.writeLine(builder.String())
+}
+
+func (engine *UCIEngine) writeLine(line string) {
+	engine.outMu.Lock()
+	defer engine.outMu.Unlock()
+	fmt.Fprintln(engine.out, line)
+}
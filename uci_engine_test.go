@@ -0,0 +1,177 @@
+package chess_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	. "github.com/CameronHonis/chess"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// firstMoveSearcher plays the first legal move, waiting for the search to be stopped when it is infinite
+type firstMoveSearcher struct {
+	boards  []*Board
+	limits  []*SearchLimits
+	options map[string]string
+	// isScoreless leaves the score out of the search info
+	isScoreless bool
+}
+
+func (searcher *firstMoveSearcher) Search(ctx context.Context, board *Board, limits *SearchLimits, onInfo func(*SearchInfo)) (*Move, error) {
+	searcher.boards = append(searcher.boards, board)
+	searcher.limits = append(searcher.limits, limits)
+	moves, err := GetLegalMoves(board)
+	if err != nil || len(moves) == 0 {
+		return nil, err
+	}
+	onInfo(&SearchInfo{Depth: 1, Score: 12, HasScore: !searcher.isScoreless, Nodes: uint64(len(moves)), PV: moves[:1]})
+	if limits.IsInfinite || limits.MoveTime > 0 {
+		<-ctx.Done()
+	}
+	return moves[0], nil
+}
+
+func (searcher *firstMoveSearcher) Options() []*UCIOption {
+	return []*UCIOption{{Name: "Hash", Type: UCI_OPTION_TYPE_SPIN, Default: "16", Min: 1, Max: 1024}}
+}
+
+func (searcher *firstMoveSearcher) SetOption(name string, value string) error {
+	if name != "Hash" {
+		return fmt.Errorf("unknown option %s", name)
+	}
+	searcher.options[name] = value
+	return nil
+}
+
+var _ = Describe("UCIEngine", func() {
+	var searcher *firstMoveSearcher
+	var toEngine *io.PipeWriter
+	var lines chan string
+	var runErr chan error
+	send := func(command string) {
+		_, err := fmt.Fprintln(toEngine, command)
+		Expect(err).ToNot(HaveOccurred())
+	}
+	BeforeEach(func() {
+		searcher = &firstMoveSearcher{options: make(map[string]string)}
+		engine := NewUCIEngine("TestEngine", "Tester", searcher)
+		engineIn, engineInWriter := io.Pipe()
+		engineOutReader, engineOut := io.Pipe()
+		toEngine = engineInWriter
+		engineLines := make(chan string, 100)
+		engineRunErr := make(chan error, 1)
+		lines, runErr = engineLines, engineRunErr
+		go func() {
+			engineRunErr <- engine.Run(engineIn, engineOut)
+			engineOut.Close()
+		}()
+		go func() {
+			scanner := bufio.NewScanner(engineOutReader)
+			for scanner.Scan() {
+				engineLines <- scanner.Text()
+			}
+			close(engineLines)
+		}()
+		DeferCleanup(func() {
+			send("quit")
+			Eventually(runErr).Should(Receive(BeNil()))
+		})
+	})
+	expectLine := func(expLine string) {
+		EventuallyWithOffset(1, lines, time.Second).Should(Receive(Equal(expLine)))
+	}
+
+	It("identifies itself and its options", func() {
+		send("uci")
+		expectLine("id name TestEngine")
+		expectLine("id author Tester")
+		expectLine("option name UCI_Chess960 type check default false")
+		expectLine("option name Hash type spin default 16 min 1 max 1024")
+		expectLine("uciok")
+		send("isready")
+		expectLine("readyok")
+	})
+	It("passes options on to the searcher", func() {
+		send("setoption name Hash value 64")
+		send("isready")
+		expectLine("readyok")
+		Expect(searcher.options).To(HaveKeyWithValue("Hash", "64"))
+	})
+	It("searches the position after the given moves", func() {
+		send("position startpos moves e2e4 e7e5")
+		send("go depth 3 wtime 60000 btime 59000 winc 1000 binc 1000")
+		expectLine("info depth 1 score cp 12 nodes 29 pv b1a3")
+		expectLine("bestmove b1a3")
+		Expect(searcher.boards[0].ToFEN()).To(Equal("rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2"))
+		Expect(searcher.limits[0]).To(Equal(&SearchLimits{
+			Depth:          3,
+			WhiteTime:      time.Minute,
+			BlackTime:      59 * time.Second,
+			WhiteIncrement: time.Second,
+			BlackIncrement: time.Second,
+		}))
+	})
+	It("passes mate and search moves limits to the searcher", func() {
+		send("position startpos")
+		send("go mate 3 searchmoves e2e4 d2d4")
+		expectLine("info depth 1 score cp 12 nodes 20 pv b1a3")
+		expectLine("bestmove b1a3")
+		Expect(searcher.limits[0].Mate).To(Equal(3))
+		Expect(searcher.limits[0].SearchMoves).To(HaveLen(2))
+		Expect(searcher.limits[0].SearchMoves[0].ToLongAlgebraic()).To(Equal("e2e4"))
+		Expect(searcher.limits[0].SearchMoves[1].ToLongAlgebraic()).To(Equal("d2d4"))
+	})
+	It("leaves the score out of info lines when the searcher has none", func() {
+		searcher.isScoreless = true
+		send("position startpos")
+		send("go depth 1")
+		expectLine("info depth 1 nodes 20 pv b1a3")
+		expectLine("bestmove b1a3")
+	})
+	It("rejects pondering", func() {
+		send("position startpos")
+		send("go ponder")
+		expectLine("info string cannot go, pondering is not supported")
+	})
+	It("searches a position given by FEN", func() {
+		send("position fen 7k/8/8/8/8/8/8/R6K w - - 0 1 moves a1a2")
+		send("go depth 1")
+		Eventually(lines, time.Second).Should(Receive(HavePrefix("info")))
+		expectLine("bestmove h8g8")
+	})
+	It("waits for stop before answering an infinite search", func() {
+		send("position startpos")
+		send("go infinite")
+		Eventually(lines, time.Second).Should(Receive(HavePrefix("info")))
+		Consistently(lines, 50*time.Millisecond).ShouldNot(Receive())
+		send("stop")
+		expectLine("bestmove b1a3")
+	})
+	It("stops the search when the move time runs out", func() {
+		send("position startpos")
+		send("go movetime 20")
+		Eventually(lines, time.Second).Should(Receive(HavePrefix("info")))
+		expectLine("bestmove b1a3")
+	})
+	It("reports bad input without quitting", func() {
+		send("position startpos moves e2e5")
+		Eventually(lines, time.Second).Should(Receive(HavePrefix("info string cannot set position")))
+		send("isready")
+		expectLine("readyok")
+	})
+	It("accepts king takes rook castling when playing Chess960", func() {
+		send("setoption name UCI_Chess960 value true")
+		send("position fen bqnbrkrn/pppppppp/8/8/8/8/PPPPPPPP/BQNBRKRN w GEge - 0 1 moves g2g3 g7g6 f1g1")
+		send("isready")
+		expectLine("readyok")
+		send("go depth 1")
+		Eventually(lines, time.Second).Should(Receive(HavePrefix("info")))
+		Eventually(lines, time.Second).Should(Receive(HavePrefix("bestmove")))
+		Expect(searcher.boards[0].GetPieceOnSquare(&Square{1, 7})).To(Equal(WHITE_KING))
+		Expect(searcher.boards[0].GetPieceOnSquare(&Square{1, 6})).To(Equal(WHITE_ROOK))
+	})
+})
@@ -0,0 +1,162 @@
+package chess
+
+// zobristKeys are the random numbers a position's key is XORed together from: one per piece on each square, one per
+// castle right, one per en passant file and one for black to move
+type zobristKeys struct {
+	pieces         [BLACK_KING + 1][64]uint64
+	castleRights   [4]uint64
+	enPassantFiles [8]uint64
+	isBlackTurn    uint64
+}
+
+var zobrist = newZobristKeys()
+
+func newZobristKeys() *zobristKeys {
+	keys := &zobristKeys{}
+	rng := xorshiftRand(0x2545f4914f6cdd1d)
+	for piece := WHITE_PAWN; piece <= BLACK_KING; piece++ {
+		for idx := 0; idx < 64; idx++ {
+			keys.pieces[piece][idx] = rng.next()
+		}
+	}
+	for idx := range keys.castleRights {
+		keys.castleRights[idx] = rng.next()
+	}
+	for file := range keys.enPassantFiles {
+		keys.enPassantFiles[file] = rng.next()
+	}
+	keys.isBlackTurn = rng.next()
+	return keys
+}
+
+// Key returns the Zobrist key of the position, which covers the pieces, the side to move, the castle rights and the
+// en passant file. The file only counts when a pawn of the side to move stands next to the pawn that can be taken,
+// so that positions differing only in an unusable en passant square share a key.
+func (position *Position) Key() uint64 {
+	return position.key
+}
+
+// computeKey works the key out from scratch, the moves keep it up to date from there
+func (position *Position) computeKey() uint64 {
+	var key uint64
+	for idx, piece := range position.mailbox {
+		if piece != EMPTY {
+			key ^= zobrist.pieces[piece][idx]
+		}
+	}
+	for idx, canCastle := range position.castleRights {
+		if canCastle {
+			key ^= zobrist.castleRights[idx]
+		}
+	}
+	key ^= position.enPassantKey()
+	if !position.isWhiteTurn {
+		key ^= zobrist.isBlackTurn
+	}
+	return key
+}
+
+// enPassantKey returns the key of the en passant file if a pawn of the side to move could capture on it, else 0
+func (position *Position) enPassantKey() uint64 {
+	if position.enPassantIdx < 0 {
+		return 0
+	}
+	pawn, pawnAttacksIdx := WHITE_PAWN, 1
+	if !position.isWhiteTurn {
+		pawn, pawnAttacksIdx = BLACK_PAWN, 0
+	}
+	// a pawn attacks the en passant square from where a pawn of the other colour on the square would attack
+	if getAttackTables().pawn[pawnAttacksIdx][position.enPassantIdx]&position.pieceBitboards[pawn] == 0 {
+		return 0
+	}
+	return zobrist.enPassantFiles[position.enPassantIdx%8]
+}
+
+// ZobristKey returns the Zobrist key of the board's position, the same key Position.Key gives. Positions reached by
+// different move orders share a key, which makes it the key for repetitions and for caching positions. The
+// BoardBuilder updates the key as it changes the board, so fields changed directly rather than through it leave the
+// key stale.
+func (board *Board) ZobristKey() uint64 {
+	if !board.hasZobristKey {
+		return board.computeZobristKey() ^ board.enPassantZobristKey()
+	}
+	return board.zobristKey ^ board.enPassantZobristKey()
+}
+
+// computeZobristKey works out the key of the board without the en passant file, which the BoardBuilder then keeps
+// up to date
+func (board *Board) computeZobristKey() uint64 {
+	key := piecesZobristKey(&board.Pieces)
+	for idx, canCastle := range []bool{
+		board.CanWhiteCastleKingside, board.CanWhiteCastleQueenside,
+		board.CanBlackCastleKingside, board.CanBlackCastleQueenside,
+	} {
+		if canCastle {
+			key ^= zobrist.castleRights[idx]
+		}
+	}
+	if !board.IsWhiteTurn {
+		key ^= zobrist.isBlackTurn
+	}
+	return key
+}
+
+func piecesZobristKey(pieces *[8][8]Piece) uint64 {
+	var key uint64
+	for rankIdx, rankPieces := range pieces {
+		for fileIdx, piece := range rankPieces {
+			if piece != EMPTY {
+				key ^= zobrist.pieces[piece][rankIdx*8+fileIdx]
+			}
+		}
+	}
+	return key
+}
+
+// enPassantZobristKey is the Board's version of Position.enPassantKey
+func (board *Board) enPassantZobristKey() uint64 {
+	if file, ok := board.capturableEnPassantFile(); ok {
+		return zobrist.enPassantFiles[file-1]
+	}
+	return 0
+}
+
+// capturableEnPassantFile returns the file of the en passant square when a pawn of the side to move stands ready
+// to capture on it
+func (board *Board) capturableEnPassantFile() (uint8, bool) {
+	enPassantSquare := board.OptEnPassantSquare
+	if enPassantSquare == nil {
+		return 0, false
+	}
+	pawn, pawnRank := WHITE_PAWN, enPassantSquare.Rank-1
+	if !board.IsWhiteTurn {
+		pawn, pawnRank = BLACK_PAWN, enPassantSquare.Rank+1
+	}
+	for _, file := range []uint8{enPassantSquare.File - 1, enPassantSquare.File + 1} {
+		square := &Square{pawnRank, file}
+		if square.IsValidBoardSquare() && board.GetPieceOnSquare(square) == pawn {
+			return enPassantSquare.File, true
+		}
+	}
+	return 0, false
+}
+
+// miniFENZobristKey returns the Zobrist key of the position written as a mini FEN, see Board.ToMiniFEN
+func miniFENZobristKey(miniFEN string) (uint64, bool) {
+	board, err := BoardFromFENWithValidation(miniFEN+" 0 1", FEN_VALIDATION_NONE)
+	if err != nil {
+		return 0, false
+	}
+	return board.ZobristKey(), true
+}
+
+// repetitionsByKeyFromMiniFENs rekeys repetition counts by mini FEN, as boards counted them before, by Zobrist key
+func repetitionsByKeyFromMiniFENs(repetitionsByMiniFEN map[string]uint8) map[uint64]uint8 {
+	repetitionsByKey := make(map[uint64]uint8, len(repetitionsByMiniFEN))
+	for miniFEN, count := range repetitionsByMiniFEN {
+		if key, ok := miniFENZobristKey(miniFEN); ok {
+			repetitionsByKey[key] = count
+		}
+	}
+	return repetitionsByKey
+}